@@ -0,0 +1,119 @@
+package jsonrepair
+
+import (
+	"sync"
+	"unsafe"
+)
+
+// maxPooledOutput is the capacity above which the output buffer of a parser
+// is not kept in the pool, so that one large document does not pin its
+// memory for the lifetime of the process
+const maxPooledOutput = 1 << 20
+
+// parserPool holds parsers for reuse by RepairBytes and JSONRepair, as a
+// parser is too large to allocate for every small document
+var parserPool = sync.Pool{
+	New: func() any { return new(Parser) },
+}
+
+// RepairBytes repairs the JSON document in src and appends the result to
+// dst, returning the extended buffer. Like append, it reuses dst when its
+// capacity suffices, so that a service can repair documents without
+// allocating:
+//
+//	buf := make([]byte, 0, 4096)
+//	for _, body := range bodies {
+//		var err error
+//		buf, err = jsonrepair.RepairBytes(buf[:0], body)
+//		if err != nil {
+//			continue
+//		}
+//		json.Unmarshal(buf, &v)
+//	}
+//
+// src is only read during the call, and dst is returned unchanged on error.
+func RepairBytes(dst, src []byte) ([]byte, error) {
+	return RepairBytesWithOptions(dst, src, Options{})
+}
+
+// RepairBytesWithOptions is like RepairBytes, applying only the repairs
+// allowed by opts
+func RepairBytesWithOptions(dst, src []byte, opts Options) ([]byte, error) {
+	p := parserPool.Get().(*Parser)
+	defer p.release()
+	return p.appendRepaired(dst, src, opts)
+}
+
+// repairString repairs text with a parser from the pool
+func repairString(text string, opts Options) (string, error) {
+	p := parserPool.Get().(*Parser)
+	defer p.release()
+	p.text = text
+	p.setOptions(opts)
+	if p.unchanged() {
+		return text, nil
+	}
+	if p.output.buf == nil && p.inputSizeError() == nil {
+		// The buffer of a repaired document is returned as a string and
+		// not reused, so it is allocated at about the size of the input
+		// rather than grown from nothing
+		size := len(text) + len(text)/8 + 8
+		if p.maxOutput > 0 {
+			size = min(size, p.maxOutput)
+		}
+		p.output.buf = make([]byte, 0, size)
+	}
+	if err := p.parse(); err != nil {
+		return "", err
+	}
+	return p.result()
+}
+
+// appendRepaired repairs src and appends the repaired document to dst
+func (p *Parser) appendRepaired(dst, src []byte, opts Options) ([]byte, error) {
+	if len(src) > 0 {
+		p.text = unsafe.String(&src[0], len(src))
+	}
+	p.setOptions(opts)
+	if p.unchanged() {
+		return append(dst, p.text...), nil
+	}
+	if err := p.parse(); err != nil {
+		return dst, err
+	}
+	if err := p.finish(); err != nil {
+		return dst, err
+	}
+	return append(dst, p.output.buf...), nil
+}
+
+// release returns a parser to the pool
+func (p *Parser) release() {
+	p.recycle()
+	parserPool.Put(p)
+}
+
+// recycle resets the parser to repair another input. It drops everything
+// referring to the input, which may be changed once RepairBytes returns,
+// and keeps the memory of its buffers for reuse.
+func (p *Parser) recycle() {
+	output := p.output
+	stack, repairs, keys := p.stack, p.repairs, p.keys
+	if output.frozen > 0 || cap(output.buf) > maxPooledOutput {
+		// Returned as a string, or too large to keep
+		output.buf = nil
+	}
+	clear(stack[:cap(stack)])
+	clear(repairs[:cap(repairs)])
+
+	*p = Parser{
+		output: outputBuffer{
+			buf:      output.buf[:0],
+			edited:   output.edited,
+			exceeded: output.exceeded,
+		},
+		stack:   stack[:0],
+		repairs: repairs[:0],
+		keys:    keys[:0],
+	}
+}
@@ -0,0 +1,148 @@
+package jsonrepair
+
+import (
+	"errors"
+	"strings"
+	"sync"
+	"testing"
+)
+
+func TestRepairBytes(t *testing.T) {
+	tests := []string{
+		`{"a": [1, 2, 3], "b": "c"}`,
+		`{name: 'John', tags: ['a', 'b',], // comment
+		"nested": {"a": [1, 2`,
+		"{\"id\": 1}\n{\"id\": 2}\n",
+		`callback({"a": True})`,
+		``,
+	}
+	for _, input := range tests {
+		expected, expectedErr := JSONRepair(input)
+		dst := []byte("prefix:")
+		output, err := RepairBytes(dst, []byte(input))
+		if (err != nil) != (expectedErr != nil) {
+			t.Errorf("RepairBytes(%q) error = %v, want %v", input, err, expectedErr)
+			continue
+		}
+		if err != nil {
+			if string(output) != "prefix:" {
+				t.Errorf("RepairBytes(%q) changed dst on error: %q", input, output)
+			}
+			continue
+		}
+		if string(output) != "prefix:"+expected {
+			t.Errorf("RepairBytes(%q) = %q, want %q", input, output, "prefix:"+expected)
+		}
+	}
+}
+
+func TestRepairBytesReusesBuffer(t *testing.T) {
+	buf := make([]byte, 0, 256)
+	for _, input := range []string{`{a: 1}`, `[1, 2, 3`, `"text"`} {
+		output, err := RepairBytes(buf[:0], []byte(input))
+		if err != nil {
+			t.Fatalf("RepairBytes(%q) error: %v", input, err)
+		}
+		if &output[0] != &buf[:1][0] {
+			t.Errorf("RepairBytes(%q) did not append to the buffer", input)
+		}
+	}
+}
+
+func TestRepairBytesWithOptions(t *testing.T) {
+	output, err := RepairBytesWithOptions(nil, []byte(`{b: 1, a: [2 3], b: 4}`), Options{Format: FormatCanonical, DuplicateKeys: DuplicateKeysLast})
+	if err != nil || string(output) != `{"a":[2,3],"b":4}` {
+		t.Errorf("RepairBytesWithOptions = %q, %v", output, err)
+	}
+
+	_, err = RepairBytesWithOptions(nil, []byte(`[1, 2, 3,]`), Options{Disable: []RepairKind{RepairTrailingComma}})
+	if !errors.Is(err, ErrRepairDisabled) {
+		t.Errorf("RepairBytesWithOptions with a disabled repair returned %v", err)
+	}
+}
+
+func TestRepairBytesDoesNotKeepInput(t *testing.T) {
+	src := []byte(`{'key': 'value', other: [1 2]}`)
+	output, err := RepairBytes(nil, src)
+	if err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	expected := string(output)
+
+	// Overwrite the input, then repair another document with the pooled parser
+	for j := range src {
+		src[j] = 'x'
+	}
+	output, err = RepairBytes(nil, []byte(`{'key': 'value', other: [1 2]}`))
+	if err != nil || string(output) != expected {
+		t.Errorf("RepairBytes after reusing a parser = %q, %v, want %q", output, err, expected)
+	}
+}
+
+func TestRepairBytesConcurrent(t *testing.T) {
+	inputs := []string{`{a: 1, b: [1 2 3]}`, `[1, 2, 3,]`, `{"valid": true}`, "{\"id\": 1}\n{\"id\": 2}"}
+	var wg sync.WaitGroup
+	for g := 0; g < 8; g++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			var buf []byte
+			for n := 0; n < 200; n++ {
+				input := inputs[n%len(inputs)]
+				expected, _ := JSONRepair(input)
+				var err error
+				buf, err = RepairBytes(buf[:0], []byte(input))
+				if err != nil || string(buf) != expected {
+					t.Errorf("RepairBytes(%q) = %q, %v, want %q", input, buf, err, expected)
+					return
+				}
+			}
+		}()
+	}
+	wg.Wait()
+}
+
+func TestRepairBytesAllocations(t *testing.T) {
+	// A recycled parser, like the ones taken from the pool
+	p := new(Parser)
+	dst := make([]byte, 0, 1024)
+	for _, input := range []string{
+		`{"a": [1, 2, 3], "b": {"c": null}}`,
+		`{"a": [1, 2, 3,], "b": {"c": None}, "d": 'e' // comment`,
+	} {
+		src := []byte(input)
+		allocs := testing.AllocsPerRun(100, func() {
+			var err error
+			dst, err = p.appendRepaired(dst[:0], src, Options{})
+			if err != nil {
+				t.Fatal(err)
+			}
+			p.recycle()
+		})
+		if allocs != 0 {
+			t.Errorf("Repairing %q allocated %v times", input, allocs)
+		}
+	}
+}
+
+func BenchmarkRepairBytes(b *testing.B) {
+	inputs := map[string]string{
+		"valid":  `{"name": "John", "age": 30, "tags": ["a", "b"], "address": {"city": "Paris"}}`,
+		"broken": `{"name": 'John', "age": 30, "tags": ["a" "b",], "address": {"city": "Paris"`,
+		"large":  "[" + strings.Repeat(`{"id": 1, "name": 'John' "tags": ["a", "b",]}, `, 1000) + "]",
+	}
+	for name, input := range inputs {
+		src := []byte(input)
+		b.Run(name, func(b *testing.B) {
+			b.ReportAllocs()
+			b.SetBytes(int64(len(src)))
+			var dst []byte
+			for i := 0; i < b.N; i++ {
+				var err error
+				if dst, err = RepairBytes(dst[:0], src); err != nil {
+					b.Fatal(err)
+				}
+			}
+		})
+	}
+}
@@ -0,0 +1,215 @@
+package jsonrepair
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"math"
+	"slices"
+	"strconv"
+	"strings"
+	"unicode/utf16"
+	"unicode/utf8"
+)
+
+// Canonicalize rewrites a valid JSON document in the JSON Canonicalization
+// Scheme of RFC 8785: without whitespace, with the keys of every object
+// sorted by their UTF-16 code units, numbers serialized like ECMAScript does
+// and strings escaped minimally. Documents with duplicate keys, numbers out
+// of the range of a float64 or strings with lone surrogates have no
+// canonical form and return an error.
+//
+// Example:
+//
+//	canonical, err := jsonrepair.Canonicalize([]byte(`{"b": 1.50, "a": [1e2]}`))
+//	fmt.Println(string(canonical)) // {"a":[100],"b":1.5}
+func Canonicalize(data []byte) ([]byte, error) {
+	if err := json.Unmarshal(data, new(json.RawMessage)); err != nil {
+		return nil, err
+	}
+	d := document{data: data}
+	root, err := d.parse()
+	if err != nil {
+		return nil, err
+	}
+	var b bytes.Buffer
+	if err := d.canonicalize(&b, root); err != nil {
+		return nil, err
+	}
+	return b.Bytes(), nil
+}
+
+// canonicalMember is a member of an object with its key decoded
+type canonicalMember struct {
+	key   string
+	units []uint16 // UTF-16 code units of key, by which members are sorted
+	value *node
+}
+
+// canonicalize writes node n to b in canonical form
+func (d *document) canonicalize(b *bytes.Buffer, n *node) error {
+	switch n.kind {
+	case '{':
+		members := make([]canonicalMember, 0, len(n.members))
+		for _, m := range n.members {
+			key, _, err := decodeString(d.data, m.start)
+			if err != nil {
+				return err
+			}
+			members = append(members, canonicalMember{key: key, units: utf16.Encode([]rune(key)), value: m.value})
+		}
+		slices.SortStableFunc(members, func(a, b canonicalMember) int {
+			return slices.Compare(a.units, b.units)
+		})
+
+		b.WriteByte('{')
+		for j, m := range members {
+			if j > 0 {
+				if m.key == members[j-1].key {
+					return fmt.Errorf("duplicate key %q", m.key)
+				}
+				b.WriteByte(',')
+			}
+			writeCanonicalString(b, m.key)
+			b.WriteByte(':')
+			if err := d.canonicalize(b, m.value); err != nil {
+				return err
+			}
+		}
+		b.WriteByte('}')
+	case '[':
+		b.WriteByte('[')
+		for j, item := range n.items {
+			if j > 0 {
+				b.WriteByte(',')
+			}
+			if err := d.canonicalize(b, item); err != nil {
+				return err
+			}
+		}
+		b.WriteByte(']')
+	case '"':
+		s, _, err := decodeString(d.data, n.start)
+		if err != nil {
+			return err
+		}
+		writeCanonicalString(b, s)
+	case '0':
+		number, err := canonicalNumber(d.raw(n))
+		if err != nil {
+			return err
+		}
+		b.WriteString(number)
+	default:
+		b.WriteString(d.raw(n))
+	}
+	return nil
+}
+
+// canonicalNumber serializes a JSON number like ECMAScript's
+// Number.prototype.toString does for the nearest float64
+func canonicalNumber(raw string) (string, error) {
+	f, err := strconv.ParseFloat(raw, 64)
+	if err != nil && (!errors.Is(err, strconv.ErrRange) || math.IsInf(f, 0)) {
+		return "", fmt.Errorf("number %s is out of range", raw)
+	}
+	if f == 0 {
+		return "0", nil // Also for -0
+	}
+	if abs := math.Abs(f); abs >= 1e-6 && abs < 1e21 {
+		return strconv.FormatFloat(f, 'f', -1, 64), nil
+	}
+
+	// ECMAScript writes the exponent without leading zeros
+	mantissa, exponent, _ := strings.Cut(strconv.FormatFloat(f, 'e', -1, 64), "e")
+	return mantissa + "e" + exponent[:1] + strings.TrimLeft(exponent[1:], "0"), nil
+}
+
+// writeCanonicalString writes s as a JSON string, escaping only quotes,
+// backslashes and control characters
+func writeCanonicalString(b *bytes.Buffer, s string) {
+	b.WriteByte('"')
+	for _, r := range s {
+		switch r {
+		case '"':
+			b.WriteString(`\"`)
+		case '\\':
+			b.WriteString(`\\`)
+		case '\b':
+			b.WriteString(`\b`)
+		case '\f':
+			b.WriteString(`\f`)
+		case '\n':
+			b.WriteString(`\n`)
+		case '\r':
+			b.WriteString(`\r`)
+		case '\t':
+			b.WriteString(`\t`)
+		default:
+			if r < 0x20 {
+				fmt.Fprintf(b, `\u%04x`, r)
+			} else {
+				b.WriteRune(r)
+			}
+		}
+	}
+	b.WriteByte('"')
+}
+
+// decodeString decodes the valid JSON string starting at data[start] and
+// returns it with the offset after its end quote. Unlike encoding/json, it
+// fails on invalid UTF-8 and lone surrogates rather than replacing them.
+func decodeString(data []byte, start int) (string, int, error) {
+	var s strings.Builder
+	i := start + 1
+	for data[i] != '"' {
+		if data[i] != '\\' {
+			r, size := utf8.DecodeRune(data[i:])
+			if r == utf8.RuneError && size == 1 {
+				return "", 0, fmt.Errorf("invalid UTF-8 at offset %d", i)
+			}
+			s.WriteRune(r)
+			i += size
+			continue
+		}
+
+		escape := data[i+1]
+		i += 2
+		switch escape {
+		case 'b':
+			s.WriteByte('\b')
+		case 'f':
+			s.WriteByte('\f')
+		case 'n':
+			s.WriteByte('\n')
+		case 'r':
+			s.WriteByte('\r')
+		case 't':
+			s.WriteByte('\t')
+		case 'u':
+			r := hexRune(data[i : i+4])
+			i += 4
+			if utf16.IsSurrogate(r) {
+				var low rune = -1
+				if i+6 <= len(data) && data[i] == '\\' && data[i+1] == 'u' {
+					low = hexRune(data[i+2 : i+6])
+				}
+				if r = utf16.DecodeRune(r, low); r == utf8.RuneError {
+					return "", 0, fmt.Errorf("lone surrogate at offset %d", i-6)
+				}
+				i += 6
+			}
+			s.WriteRune(r)
+		default:
+			s.WriteByte(escape) // " \ and /
+		}
+	}
+	return s.String(), i + 1, nil
+}
+
+// hexRune returns the rune of the four hexadecimal digits of a \u escape
+func hexRune(digits []byte) rune {
+	r, _ := strconv.ParseUint(string(digits), 16, 32)
+	return rune(r)
+}
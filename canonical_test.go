@@ -0,0 +1,109 @@
+package jsonrepair
+
+import (
+	"bytes"
+	"errors"
+	"strings"
+	"testing"
+)
+
+func TestCanonicalize(t *testing.T) {
+	tests := []struct {
+		name     string
+		input    string
+		expected string
+	}{
+		{"whitespace", " { \"a\" : [ 1 , true , null ] } ", `{"a":[1,true,null]}`},
+		{"sorted keys", `{"b": 1, "a": {"d": 2, "c": 3}}`, `{"a":{"c":3,"d":2},"b":1}`},
+		{"sorted by utf-16 code units",
+			`{"\u20ac": 1, "\r": 2, "\ufb33": 3, "1": 4, "\ud83d\ude00": 5, "\u0080": 6, "\u00f6": 7}`,
+			"{\"\\r\":2,\"1\":4,\"\u0080\":6,\"ö\":7,\"€\":1,\"😀\":5,\"\ufb33\":3}"},
+		{"string escapes", `["\u0041\/\"\\", "\b\f\n\r\t\u000f\u001F", "<>&\u2028"]`, "[\"A/\\\"\\\\\",\"\\b\\f\\n\\r\\t\\u000f\\u001f\",\"<>&\u2028\"]"},
+		{"integers", `[0, -0, 1, -12, 1e2, 4.50, 2e-3]`, `[0,0,1,-12,100,4.5,0.002]`},
+		{"large and small numbers", `[1e20, 1e21, 1E30, 0.000001, 1e-7, 0.000000000000000000000000001]`,
+			`[100000000000000000000,1e+21,1e+30,0.000001,1e-7,1e-27]`},
+		{"nearest float64", `[333333333.33333329, 5e-324, 1.7976931348623157e308, 9007199254740993]`,
+			`[333333333.3333333,5e-324,1.7976931348623157e+308,9007199254740992]`},
+		{"scalar", ` "abc" `, `"abc"`},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			result, err := Canonicalize([]byte(tt.input))
+			if err != nil {
+				t.Fatalf("Canonicalize(%q) error: %v", tt.input, err)
+			}
+			if string(result) != tt.expected {
+				t.Errorf("Canonicalize(%q) = %q, want %q", tt.input, result, tt.expected)
+			}
+		})
+	}
+}
+
+func TestCanonicalizeErrors(t *testing.T) {
+	tests := []struct {
+		name    string
+		input   string
+		message string
+	}{
+		{"invalid JSON", `{a: 1}`, "invalid character"},
+		{"duplicate key", `{"a": 1, "b": 2, "a": 3}`, `duplicate key "a"`},
+		{"escaped duplicate key", `{"a": 1, "\u0061": 2}`, `duplicate key "a"`},
+		{"number out of range", `[1e400]`, "number 1e400 is out of range"},
+		{"lone high surrogate", `"\ud800"`, "lone surrogate"},
+		{"lone low surrogate", `"x\udc00"`, "lone surrogate"},
+		{"invalid UTF-8", "\"\xff\"", "invalid UTF-8"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			_, err := Canonicalize([]byte(tt.input))
+			if err == nil || !strings.Contains(err.Error(), tt.message) {
+				t.Errorf("Canonicalize(%q) error = %v, want %q", tt.input, err, tt.message)
+			}
+		})
+	}
+}
+
+func TestFormatCanonical(t *testing.T) {
+	input := "```json\n{b: 1.50, a: [1E2, 'x',], // note\n c: {z: None, y: True}}\n```"
+	expected := `{"a":[100,"x"],"b":1.5,"c":{"y":true,"z":null}}`
+	opts := Options{Format: FormatCanonical}
+
+	result, err := JSONRepairWithOptions(input, opts)
+	if err != nil || result != expected {
+		t.Errorf("JSONRepairWithOptions(%q) = %q, %v, want %q", input, result, err, expected)
+	}
+
+	var out bytes.Buffer
+	if err := RepairStreamWithOptions(strings.NewReader(input), &out, opts); err != nil || out.String() != expected {
+		t.Errorf("RepairStreamWithOptions(%q) = %q, %v, want %q", input, out.String(), err, expected)
+	}
+
+	r := NewRepairerWithOptions(opts)
+	r.WriteString(input[:20])
+	if result, err := r.Snapshot(); err != nil || result != `{"a":null,"b":1.5}` {
+		t.Errorf("Snapshot after %q = %q, %v", input[:20], result, err)
+	}
+	r.WriteString(input[20:])
+	if result, err := r.Snapshot(); err != nil || result != expected {
+		t.Errorf("Snapshot after %q = %q, %v, want %q", input, result, err, expected)
+	}
+}
+
+func TestFormatCanonicalError(t *testing.T) {
+	_, err := JSONRepairWithOptions(`{a: 1, a: 2}`, Options{Format: FormatCanonical})
+	var repairErr *JSONRepairError
+	if !errors.As(err, &repairErr) || !errors.Is(err, ErrNotCanonical) {
+		t.Fatalf("Expected a canonicalization error, got %v", err)
+	}
+	if repairErr.Message != `Cannot canonicalize: duplicate key "a"` {
+		t.Errorf("Unexpected message %q", repairErr.Message)
+	}
+
+	var out bytes.Buffer
+	err = RepairStreamWithOptions(strings.NewReader(`[1e999]`), &out, Options{Format: FormatCanonical})
+	if !errors.Is(err, ErrNotCanonical) || out.Len() > 0 {
+		t.Errorf("RepairStreamWithOptions wrote %q, error %v", out.String(), err)
+	}
+}
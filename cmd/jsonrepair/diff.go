@@ -0,0 +1,261 @@
+package main
+
+import (
+	"fmt"
+	"io"
+	"slices"
+	"strings"
+
+	jsonrepair "github.com/wokito/jsonrepair-go"
+)
+
+// diffContext is the number of unchanged lines shown around a change
+const diffContext = 3
+
+// op is an operation of a line diff
+type op struct {
+	kind byte // ' ' for an unchanged line, '-' for a deleted one, '+' for an inserted one
+	a, b int  // Line index in the original and in the repaired text
+}
+
+// writeDiff writes a unified diff between the original and the repaired
+// text of the file name to w. Each hunk is annotated with the kinds of the
+// repairs that were applied in it.
+func writeDiff(w io.Writer, name, original, repaired string, repairs []jsonrepair.Repair) {
+	a, b := splitLines(original), splitLines(repaired)
+	ops := diffLines(a, b)
+	if !slices.ContainsFunc(ops, func(o op) bool { return o.kind != ' ' }) {
+		return
+	}
+
+	// Byte offset of the start of each original line
+	starts := make([]int, len(a)+1)
+	for j, line := range a {
+		starts[j+1] = starts[j] + len(line)
+	}
+
+	fmt.Fprintf(w, "--- %s.orig\n+++ %s\n", name, name)
+	for start := 0; start < len(ops); {
+		// Find the next change and extend the hunk while changes are close
+		first := slices.IndexFunc(ops[start:], func(o op) bool { return o.kind != ' ' })
+		if first == -1 {
+			break
+		}
+		first += start
+		end := first
+		for j := first; j < len(ops) && j <= end+2*diffContext; j++ {
+			if ops[j].kind != ' ' {
+				end = j
+			}
+		}
+		from := max(first-diffContext, start)
+		to := min(end+diffContext+1, len(ops))
+		hunk := ops[from:to]
+		start = to
+
+		aStart, aCount, bStart, bCount := hunkRange(hunk)
+		fmt.Fprintf(w, "@@ -%s +%s @@", formatRange(aStart, aCount), formatRange(bStart, bCount))
+		if kinds := repairKinds(repairs, starts[aStart], starts[aStart+aCount], len(original)); kinds != "" {
+			fmt.Fprintf(w, " %s", kinds)
+		}
+		fmt.Fprintln(w)
+
+		for _, o := range hunk {
+			line := b[o.b]
+			if o.kind != '+' {
+				line = a[o.a]
+			}
+			fmt.Fprintf(w, "%c%s", o.kind, line)
+			if !strings.HasSuffix(line, "\n") {
+				fmt.Fprint(w, "\n\\ No newline at end of file\n")
+			}
+		}
+	}
+}
+
+// hunkRange returns the first line and the number of lines of a hunk in
+// the original and in the repaired text
+func hunkRange(hunk []op) (aStart, aCount, bStart, bCount int) {
+	aStart, bStart = hunk[0].a, hunk[0].b
+	for _, o := range hunk {
+		if o.kind != '+' {
+			aCount++
+		}
+		if o.kind != '-' {
+			bCount++
+		}
+	}
+	return aStart, aCount, bStart, bCount
+}
+
+// formatRange formats a range of lines for a hunk header, counting lines
+// from 1
+func formatRange(start, count int) string {
+	switch count {
+	case 0:
+		return fmt.Sprintf("%d,0", start)
+	case 1:
+		return fmt.Sprintf("%d", start+1)
+	}
+	return fmt.Sprintf("%d,%d", start+1, count)
+}
+
+// repairKinds returns the distinct kinds of the repairs applied between
+// the input offsets start and end, separated by commas. Repairs at the end
+// of the input belong to the last line.
+func repairKinds(repairs []jsonrepair.Repair, start, end, size int) string {
+	var kinds []string
+	for _, r := range repairs {
+		if r.InputOffset >= start && (r.InputOffset < end || end == size) {
+			if kind := r.Kind.String(); !slices.Contains(kinds, kind) {
+				kinds = append(kinds, kind)
+			}
+		}
+	}
+	return strings.Join(kinds, ", ")
+}
+
+// splitLines splits text into lines, keeping their line endings
+func splitLines(text string) []string {
+	lines := strings.SplitAfter(text, "\n")
+	if lines[len(lines)-1] == "" {
+		lines = lines[:len(lines)-1]
+	}
+	return lines
+}
+
+// diffLines returns the shortest edit script turning the lines a into the
+// lines b, computed with the linear space variant of the Myers algorithm.
+// Deleted lines are listed before the lines inserted in their place.
+func diffLines(a, b []string) []op {
+	d := differ{a: a, b: b}
+	d.diff(0, len(a), 0, len(b))
+
+	// Move the deletions of each change before its insertions
+	for start := 0; start < len(d.ops); {
+		if d.ops[start].kind == ' ' {
+			start++
+			continue
+		}
+		end := start
+		for end < len(d.ops) && d.ops[end].kind != ' ' {
+			end++
+		}
+		x, y := d.ops[start].a, d.ops[start].b
+		change := d.ops[start:end]
+		deleted := 0
+		for _, o := range change {
+			if o.kind == '-' {
+				deleted++
+			}
+		}
+		for j := range change {
+			if j < deleted {
+				change[j] = op{'-', x + j, y}
+			} else {
+				change[j] = op{'+', x + deleted, y + j - deleted}
+			}
+		}
+		start = end
+	}
+	return d.ops
+}
+
+// differ computes a line diff, splitting it recursively at the middle
+// snake of the shortest edit script, so that memory stays linear in the
+// number of lines
+type differ struct {
+	a, b []string
+	ops  []op
+}
+
+// diff appends the operations turning the lines a[a0:a1] into b[b0:b1]
+func (d *differ) diff(a0, a1, b0, b1 int) {
+	// Common prefix and suffix
+	for a0 < a1 && b0 < b1 && d.a[a0] == d.b[b0] {
+		d.ops = append(d.ops, op{' ', a0, b0})
+		a0++
+		b0++
+	}
+	suffix := 0
+	for a0 < a1-suffix && b0 < b1-suffix && d.a[a1-suffix-1] == d.b[b1-suffix-1] {
+		suffix++
+	}
+	a1 -= suffix
+	b1 -= suffix
+
+	switch {
+	case a0 == a1:
+		for y := b0; y < b1; y++ {
+			d.ops = append(d.ops, op{'+', a0, y})
+		}
+	case b0 == b1:
+		for x := a0; x < a1; x++ {
+			d.ops = append(d.ops, op{'-', x, b0})
+		}
+	default:
+		x, y, u, v := d.middleSnake(a0, a1, b0, b1)
+		d.diff(a0, x, b0, y)
+		for ; x < u; x, y = x+1, y+1 {
+			d.ops = append(d.ops, op{' ', x, y})
+		}
+		d.diff(u, a1, v, b1)
+	}
+
+	for j := 0; j < suffix; j++ {
+		d.ops = append(d.ops, op{' ', a1 + j, b1 + j})
+	}
+}
+
+// middleSnake returns the start x, y and the end u, v of the middle snake
+// of the shortest edit script turning a[a0:a1] into b[b0:b1], found by
+// searching from both ends at once
+func (d *differ) middleSnake(a0, a1, b0, b1 int) (x, y, u, v int) {
+	n, m := a1-a0, b1-b0
+	delta := n - m
+	limit := (n + m + 1) / 2
+	offset := limit + 1
+	forward := make([]int, 2*limit+3)  // Furthest x on each diagonal from the start
+	backward := make([]int, 2*limit+3) // Furthest distance from the end on each diagonal
+
+	for step := 0; step <= limit; step++ {
+		for k := -step; k <= step; k += 2 {
+			var x int
+			if k == -step || (k != step && forward[offset+k-1] < forward[offset+k+1]) {
+				x = forward[offset+k+1]
+			} else {
+				x = forward[offset+k-1] + 1
+			}
+			y := x - k
+			startX, startY := x, y
+			for x < n && y < m && d.a[a0+x] == d.b[b0+y] {
+				x++
+				y++
+			}
+			forward[offset+k] = x
+			if r := delta - k; delta%2 != 0 && r >= -(step-1) && r <= step-1 && x+backward[offset+r] >= n {
+				return a0 + startX, b0 + startY, a0 + x, b0 + y
+			}
+		}
+
+		for r := -step; r <= step; r += 2 {
+			var x int
+			if r == -step || (r != step && backward[offset+r-1] < backward[offset+r+1]) {
+				x = backward[offset+r+1]
+			} else {
+				x = backward[offset+r-1] + 1
+			}
+			y := x - r
+			startX, startY := x, y
+			for x < n && y < m && d.a[a1-x-1] == d.b[b1-y-1] {
+				x++
+				y++
+			}
+			backward[offset+r] = x
+			if k := delta - r; delta%2 == 0 && k >= -step && k <= step && x+forward[offset+k] >= n {
+				return a1 - x, b1 - y, a1 - startX, b1 - startY
+			}
+		}
+	}
+	panic("unreachable")
+}
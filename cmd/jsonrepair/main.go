@@ -0,0 +1,256 @@
+// Command jsonrepair repairs invalid JSON documents.
+//
+// Usage:
+//
+//	jsonrepair [flags] [file ...]
+//
+// Without files, or with the file "-", the document is read from standard
+// input. Files may be glob patterns like "data/*.json". The repaired
+// documents are written to standard output, unless --output or --overwrite
+// is given. When a document cannot be repaired, the error is reported as
+// file:line:column and jsonrepair exits with status 1.
+//
+// With --check, the documents are not written: each repair is reported as
+// file:line:column and jsonrepair exits with status 1 when any document needs
+// one. With --diff, a unified diff of the repairs is written instead of the
+// repaired documents.
+package main
+
+import (
+	"bytes"
+	"errors"
+	"flag"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"slices"
+	"strings"
+	"unicode/utf8"
+
+	jsonrepair "github.com/wokito/jsonrepair-go"
+)
+
+const (
+	exitOK    = 0
+	exitError = 1 // A document could not be repaired, read or written, or needs repairs with --check
+	exitUsage = 2 // Invalid flags or arguments
+)
+
+const usage = `Usage: jsonrepair [flags] [file ...]
+
+Repair invalid JSON documents. Without files, or with the file "-", the
+document is read from standard input. Files may be glob patterns like
+"data/*.json".
+
+Flags:
+  -o, --output FILE   write the repaired document to FILE instead of standard output
+  -w, --overwrite     repair the files in place
+      --check         report the repairs without writing, exit with status 1 if any
+      --diff          write a unified diff of the repairs instead of the documents
+  -h, --help          show this help
+`
+
+func main() {
+	os.Exit(run(os.Args[1:], os.Stdin, os.Stdout, os.Stderr))
+}
+
+// config holds the parsed command line
+type config struct {
+	output    string
+	overwrite bool
+	check     bool
+	diff      bool
+	files     []string
+}
+
+// run runs the command with the given arguments and returns its exit status
+func run(args []string, stdin io.Reader, stdout, stderr io.Writer) int {
+	cfg, err := parseArgs(args)
+	if errors.Is(err, flag.ErrHelp) {
+		fmt.Fprint(stdout, usage)
+		return exitOK
+	}
+	if err != nil {
+		fmt.Fprintf(stderr, "jsonrepair: %v\n", err)
+		fmt.Fprint(stderr, "Run 'jsonrepair --help' for usage.\n")
+		return exitUsage
+	}
+
+	// The output file is only written once the document is repaired
+	var out io.Writer = stdout
+	var buf bytes.Buffer
+	if cfg.output != "" {
+		out = &buf
+	}
+
+	status := exitOK
+	for _, name := range cfg.files {
+		changed, err := repairFile(name, cfg, stdin, out)
+		if err != nil {
+			reportError(stderr, name, err)
+			status = exitError
+		} else if changed && cfg.check {
+			status = exitError
+		}
+	}
+	if cfg.output != "" && status == exitOK {
+		if err := os.WriteFile(cfg.output, buf.Bytes(), 0o644); err != nil {
+			reportError(stderr, cfg.output, err)
+			status = exitError
+		}
+	}
+	return status
+}
+
+// parseArgs parses the flags and expands the file arguments
+func parseArgs(args []string) (*config, error) {
+	cfg := &config{}
+	flags := flag.NewFlagSet("jsonrepair", flag.ContinueOnError)
+	flags.SetOutput(io.Discard)
+	flags.StringVar(&cfg.output, "o", "", "")
+	flags.StringVar(&cfg.output, "output", "", "")
+	flags.BoolVar(&cfg.overwrite, "w", false, "")
+	flags.BoolVar(&cfg.overwrite, "overwrite", false, "")
+	flags.BoolVar(&cfg.check, "check", false, "")
+	flags.BoolVar(&cfg.diff, "diff", false, "")
+	if err := flags.Parse(args); err != nil {
+		return nil, err
+	}
+
+	for _, arg := range flags.Args() {
+		if arg == "-" || !strings.ContainsAny(arg, "*?[") {
+			cfg.files = append(cfg.files, arg)
+			continue
+		}
+		matches, err := filepath.Glob(arg)
+		if err != nil {
+			return nil, fmt.Errorf("invalid pattern %q", arg)
+		}
+		if len(matches) == 0 {
+			return nil, fmt.Errorf("no files match %q", arg)
+		}
+		cfg.files = append(cfg.files, matches...)
+	}
+	if len(cfg.files) == 0 {
+		cfg.files = []string{"-"}
+	}
+
+	switch {
+	case cfg.overwrite && cfg.output != "":
+		return nil, errors.New("--output and --overwrite cannot be combined")
+	case cfg.overwrite && slices.Contains(cfg.files, "-"):
+		return nil, errors.New("--overwrite needs files, not standard input")
+	case (cfg.check || cfg.diff) && (cfg.overwrite || cfg.output != ""):
+		return nil, errors.New("--check and --diff cannot be combined with --output or --overwrite")
+	case cfg.output != "" && len(cfg.files) > 1:
+		return nil, errors.New("--output needs a single input")
+	}
+	return cfg, nil
+}
+
+// repairFile repairs the file name, or standard input for "-", and writes
+// the result to out or back to the file. It reports whether the document
+// was changed by the repair.
+func repairFile(name string, cfg *config, stdin io.Reader, out io.Writer) (bool, error) {
+	var data []byte
+	var err error
+	if name == "-" {
+		data, err = io.ReadAll(stdin)
+	} else {
+		data, err = os.ReadFile(name)
+	}
+	if err != nil {
+		return false, err
+	}
+
+	original := string(data)
+	result, err := jsonrepair.JSONRepairWithReport(original, jsonrepair.Options{})
+	if err != nil {
+		return false, err
+	}
+	changed := result.Output != original
+
+	switch {
+	case cfg.diff:
+		writeDiff(out, displayName(name), original, result.Output, result.Repairs)
+		return changed, nil
+	case cfg.check:
+		for _, r := range result.Repairs {
+			line, column := position(original, r.InputOffset)
+			fmt.Fprintf(out, "%s:%d:%d: %s\n", displayName(name), line, column, r.Kind)
+		}
+		return changed, nil
+	case cfg.overwrite:
+		if !changed {
+			return false, nil
+		}
+		return true, writeFile(name, result.Output)
+	}
+
+	if _, err := io.WriteString(out, result.Output); err != nil {
+		return changed, err
+	}
+	if !strings.HasSuffix(result.Output, "\n") {
+		_, err = io.WriteString(out, "\n")
+	}
+	return changed, err
+}
+
+// position returns the line and column of the byte offset in text, counted
+// in runes from 1
+func position(text string, offset int) (line, column int) {
+	before := text[:offset]
+	lineStart := strings.LastIndexByte(before, '\n') + 1
+	return strings.Count(before, "\n") + 1, utf8.RuneCountInString(before[lineStart:]) + 1
+}
+
+// displayName returns the name of a file in messages
+func displayName(name string) string {
+	if name == "-" {
+		return "<stdin>"
+	}
+	return name
+}
+
+// writeFile replaces the contents of the file name by data, through a
+// temporary file so the file is never left half written
+func writeFile(name, data string) error {
+	info, err := os.Stat(name)
+	if err != nil {
+		return err
+	}
+	tmp, err := os.CreateTemp(filepath.Dir(name), "."+filepath.Base(name)+".*")
+	if err != nil {
+		return err
+	}
+	defer os.Remove(tmp.Name())
+
+	if _, err := tmp.WriteString(data); err != nil {
+		tmp.Close()
+		return err
+	}
+	if err := tmp.Chmod(info.Mode().Perm()); err != nil {
+		tmp.Close()
+		return err
+	}
+	if err := tmp.Close(); err != nil {
+		return err
+	}
+	return os.Rename(tmp.Name(), name)
+}
+
+// reportError writes err for the file name to w, as file:line:column
+// followed by the offending line for a repair error
+func reportError(w io.Writer, name string, err error) {
+	name = displayName(name)
+	var repairErr *jsonrepair.JSONRepairError
+	if !errors.As(err, &repairErr) {
+		fmt.Fprintf(w, "jsonrepair: %v\n", err)
+		return
+	}
+	fmt.Fprintf(w, "%s:%d:%d: %s\n", name, repairErr.Line, repairErr.Column, repairErr.Message)
+	for _, line := range strings.Split(repairErr.Snippet, "\n") {
+		fmt.Fprintf(w, "\t%s\n", line)
+	}
+}
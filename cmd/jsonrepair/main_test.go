@@ -0,0 +1,231 @@
+package main
+
+import (
+	"bytes"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+// writeFiles creates the files in a temporary directory and returns it
+func writeFiles(t *testing.T, files map[string]string) string {
+	t.Helper()
+	dir := t.TempDir()
+	for name, content := range files {
+		if err := os.WriteFile(filepath.Join(dir, name), []byte(content), 0o600); err != nil {
+			t.Fatal(err)
+		}
+	}
+	return dir
+}
+
+func readFile(t *testing.T, name string) string {
+	t.Helper()
+	data, err := os.ReadFile(name)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return string(data)
+}
+
+func TestRunStdin(t *testing.T) {
+	var stdout, stderr bytes.Buffer
+	status := run(nil, strings.NewReader("{name: 'John',}"), &stdout, &stderr)
+	if status != exitOK || stdout.String() != "{\"name\": \"John\"}\n" || stderr.Len() != 0 {
+		t.Errorf("run = %d, stdout %q, stderr %q", status, stdout.String(), stderr.String())
+	}
+}
+
+func TestRunFiles(t *testing.T) {
+	dir := writeFiles(t, map[string]string{
+		"a.json": "[1, 2,]\n",
+		"b.json": "{'b': True}\n",
+		"c.txt":  "[3",
+	})
+
+	var stdout, stderr bytes.Buffer
+	status := run([]string{filepath.Join(dir, "*.json"), filepath.Join(dir, "c.txt")}, nil, &stdout, &stderr)
+	expected := "[1, 2]\n{\"b\": true}\n[3]\n"
+	if status != exitOK || stdout.String() != expected {
+		t.Errorf("run = %d, stdout %q, stderr %q", status, stdout.String(), stderr.String())
+	}
+}
+
+func TestRunOutput(t *testing.T) {
+	dir := writeFiles(t, map[string]string{"in.json": "{a: 1}"})
+	output := filepath.Join(dir, "out.json")
+
+	var stdout, stderr bytes.Buffer
+	status := run([]string{"--output", output, filepath.Join(dir, "in.json")}, nil, &stdout, &stderr)
+	if status != exitOK || stdout.Len() != 0 {
+		t.Fatalf("run = %d, stdout %q, stderr %q", status, stdout.String(), stderr.String())
+	}
+	if actual := readFile(t, output); actual != "{\"a\": 1}\n" {
+		t.Errorf("output file = %q", actual)
+	}
+
+	status = run([]string{"-o", output}, strings.NewReader("[1] x"), &stdout, &stderr)
+	if status != exitError {
+		t.Errorf("run = %d, want %d", status, exitError)
+	}
+	if actual := readFile(t, output); actual != "{\"a\": 1}\n" {
+		t.Errorf("output file after an error = %q, want it unchanged", actual)
+	}
+}
+
+func TestRunOverwrite(t *testing.T) {
+	dir := writeFiles(t, map[string]string{
+		"a.json": "[1, 2,]\n",
+		"b.json": "[3]\n",
+		"c.json": "{\"c\": 1}}x",
+	})
+
+	var stdout, stderr bytes.Buffer
+	status := run([]string{"-w", filepath.Join(dir, "*.json")}, nil, &stdout, &stderr)
+	if status != exitError || stdout.Len() != 0 {
+		t.Errorf("run = %d, stdout %q", status, stdout.String())
+	}
+	if actual := readFile(t, filepath.Join(dir, "a.json")); actual != "[1, 2]\n" {
+		t.Errorf("a.json = %q", actual)
+	}
+	if actual := readFile(t, filepath.Join(dir, "c.json")); actual != "{\"c\": 1}}x" {
+		t.Errorf("c.json = %q, want it unchanged", actual)
+	}
+
+	expected := filepath.Join(dir, "c.json") + ":1:10: Unexpected character 'x'\n" +
+		"\t{\"c\": 1}}x\n" +
+		"\t         ^\n"
+	if stderr.String() != expected {
+		t.Errorf("stderr =\n%s\nwant\n%s", stderr.String(), expected)
+	}
+
+	entries, _ := os.ReadDir(dir)
+	if len(entries) != 3 {
+		t.Errorf("temporary files left behind: %v", entries)
+	}
+}
+
+func TestRunUsage(t *testing.T) {
+	dir := writeFiles(t, map[string]string{"a.json": "[]", "b.json": "[]"})
+	tests := [][]string{
+		{"--unknown"},
+		{"-w"},
+		{"-w", "-o", "out.json", filepath.Join(dir, "a.json")},
+		{"-o", "out.json", filepath.Join(dir, "*.json")},
+		{filepath.Join(dir, "*.yaml")},
+	}
+	for _, args := range tests {
+		var stdout, stderr bytes.Buffer
+		if status := run(args, strings.NewReader(""), &stdout, &stderr); status != exitUsage {
+			t.Errorf("run(%q) = %d, want %d; stderr %q", args, status, exitUsage, stderr.String())
+		}
+	}
+
+	var stdout, stderr bytes.Buffer
+	if status := run([]string{"--help"}, nil, &stdout, &stderr); status != exitOK || !strings.HasPrefix(stdout.String(), "Usage:") {
+		t.Errorf("run(--help) = %d, stdout %q", status, stdout.String())
+	}
+}
+
+func TestRunMissingFile(t *testing.T) {
+	var stdout, stderr bytes.Buffer
+	status := run([]string{filepath.Join(t.TempDir(), "missing.json")}, nil, &stdout, &stderr)
+	if status != exitError || !strings.Contains(stderr.String(), "missing.json") {
+		t.Errorf("run = %d, stderr %q", status, stderr.String())
+	}
+}
+
+func TestRunCheck(t *testing.T) {
+	dir := writeFiles(t, map[string]string{
+		"valid.json":   "{\"a\": [1, 2]}\n",
+		"invalid.json": "{\n  a: 'x',\n  \"b\": [1, 2,]\n}\n",
+	})
+
+	var stdout, stderr bytes.Buffer
+	status := run([]string{"--check", filepath.Join(dir, "valid.json")}, nil, &stdout, &stderr)
+	if status != exitOK || stdout.Len() != 0 {
+		t.Errorf("run = %d, stdout %q", status, stdout.String())
+	}
+
+	invalid := filepath.Join(dir, "invalid.json")
+	status = run([]string{"--check", filepath.Join(dir, "*.json")}, nil, &stdout, &stderr)
+	expected := invalid + ":2:3: unquoted string\n" +
+		invalid + ":2:6: quotes\n" +
+		invalid + ":2:8: quotes\n" +
+		invalid + ":3:13: trailing comma\n"
+	if status != exitError || stdout.String() != expected {
+		t.Errorf("run = %d, stdout =\n%s\nwant\n%s", status, stdout.String(), expected)
+	}
+	if actual := readFile(t, invalid); actual != "{\n  a: 'x',\n  \"b\": [1, 2,]\n}\n" {
+		t.Errorf("invalid.json = %q, want it unchanged", actual)
+	}
+}
+
+func TestRunDiff(t *testing.T) {
+	var lines []string
+	for j := 0; j < 12; j++ {
+		lines = append(lines, "  \"k"+strings.Repeat("x", j)+"\": 1,")
+	}
+	input := "{\n  a: 1,\n" + strings.Join(lines, "\n") + "\n  \"z\": [1, 2,]\n}"
+
+	var stdout, stderr bytes.Buffer
+	status := run([]string{"--diff"}, strings.NewReader(input), &stdout, &stderr)
+	expected := `--- <stdin>.orig
++++ <stdin>
+@@ -1,5 +1,5 @@ unquoted string
+ {
+-  a: 1,
++  "a": 1,
+   "k": 1,
+   "kx": 1,
+   "kxx": 1,
+@@ -12,5 +12,5 @@ trailing comma
+   "kxxxxxxxxx": 1,
+   "kxxxxxxxxxx": 1,
+   "kxxxxxxxxxxx": 1,
+-  "z": [1, 2,]
++  "z": [1, 2]
+ }
+\ No newline at end of file
+`
+	if status != exitOK || stdout.String() != expected {
+		t.Errorf("run = %d, stdout =\n%s\nwant\n%s", status, stdout.String(), expected)
+	}
+
+	stdout.Reset()
+	status = run([]string{"--diff", "--check"}, strings.NewReader("[1, 2"), &stdout, &stderr)
+	expected = "--- <stdin>.orig\n+++ <stdin>\n@@ -1 +1 @@ missing bracket\n-[1, 2\n\\ No newline at end of file\n+[1, 2]\n\\ No newline at end of file\n"
+	if status != exitError || stdout.String() != expected {
+		t.Errorf("run = %d, stdout =\n%s\nwant\n%s", status, stdout.String(), expected)
+	}
+
+	stdout.Reset()
+	status = run([]string{"--diff"}, strings.NewReader("[1, 2]\n"), &stdout, &stderr)
+	if status != exitOK || stdout.Len() != 0 {
+		t.Errorf("run = %d, stdout %q", status, stdout.String())
+	}
+}
+
+func TestDiffLines(t *testing.T) {
+	tests := []struct {
+		a, b     string
+		expected string
+	}{
+		{"", "", ""},
+		{"a\n", "", "-"},
+		{"", "a\n", "+"},
+		{"a\nb\nc\n", "a\nc\n", " - "},
+		{"a\nb\nc\n", "a\nx\nc\nd\n", " -+ +"},
+		{"a\nb\n", "x\ny\n", "--++"},
+	}
+	for _, tt := range tests {
+		var actual []byte
+		for _, o := range diffLines(splitLines(tt.a), splitLines(tt.b)) {
+			actual = append(actual, o.kind)
+		}
+		if string(actual) != tt.expected {
+			t.Errorf("diffLines(%q, %q) = %q, want %q", tt.a, tt.b, actual, tt.expected)
+		}
+	}
+}
@@ -0,0 +1,628 @@
+package jsonrepair
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"math"
+	"reflect"
+	"slices"
+	"strconv"
+	"strings"
+)
+
+// RepairInto repairs text, coerces the repaired document to the shape of T
+// and decodes it into a value of type T. Next to the syntactic repairs, it
+// reports every change made to match T:
+//
+//   - RepairTypeCoercion: a string, number or boolean converted to the type
+//     of the field, like "5" to 5 or 1 to true
+//   - RepairWrapArray: a single value wrapped into an array for a slice
+//   - RepairMissingField: a missing field added with its zero value. Fields
+//     without omitempty in their json tag are required.
+//   - RepairUnknownField: a key that matches no field of a struct removed
+//
+// The output offsets of these repairs refer to the document before it was
+// coerced. Decode errors are returned as a *DecodeError.
+//
+// Example:
+//
+//	type User struct {
+//		Name string   `json:"name"`
+//		Age  int      `json:"age"`
+//		Tags []string `json:"tags"`
+//	}
+//	user, repairs, err := jsonrepair.RepairInto[User](`{name: 'John', age: "30", tags: 'admin'}`)
+func RepairInto[T any](text string) (T, []Repair, error) {
+	return RepairIntoWithOptions[T](text, Options{})
+}
+
+// RepairIntoWithOptions is like RepairInto, applying only the repairs
+// allowed by opts. Disabled coercions are left to encoding/json, which
+// typically fails to decode the value.
+func RepairIntoWithOptions[T any](text string, opts Options) (T, []Repair, error) {
+	var value T
+	parser := NewParserWithOptions(text, opts)
+	parser.enableReport()
+	output, err := parser.Parse()
+	if err != nil {
+		return value, nil, err
+	}
+	repairs := parser.report()
+	if !json.Valid([]byte(output)) {
+		// Some input is repaired into a document that is still not valid
+		return value, repairs, syntaxError([]byte(output))
+	}
+
+	c := coercer{document: document{data: []byte(output), repairs: repairs}, parser: parser}
+	root, err := c.parse()
+	if err != nil {
+		return value, repairs, err
+	}
+	var coerced bytes.Buffer
+	c.coerce(&coerced, root, reflect.TypeOf(&value).Elem())
+
+	repairs = append(repairs, c.coercions...)
+	slices.SortStableFunc(repairs, func(a, b Repair) int {
+		return a.InputOffset - b.InputOffset
+	})
+	if err := decode(coerced.Bytes(), &value, json.NewDecoder); err != nil {
+		return value, repairs, err
+	}
+	return value, repairs, nil
+}
+
+// node is a value in a valid JSON document
+type node struct {
+	kind    byte // One of { [ " 0 t f n
+	start   int  // Offset of the value in the document
+	end     int  // Offset after the value
+	closing int  // Offset of the end bracket of an object or array
+	members []member
+	items   []*node
+}
+
+// member is a key/value pair of an object
+type member struct {
+	key   string // Decoded key
+	start int    // Offset of the key
+	value *node
+}
+
+// document is a repaired, valid JSON document
+type document struct {
+	data    []byte
+	i       int
+	repairs []Repair // Syntactic repairs, used to map offsets to the input
+}
+
+// coercer rewrites a repaired document to match a Go type
+type coercer struct {
+	document
+	parser    *Parser
+	coercions []Repair
+}
+
+// errInvalidDocument is returned by document.parse when the document is
+// not valid JSON
+var errInvalidDocument = errors.New("invalid JSON document")
+
+// parse reads the document into a tree of nodes. The document is expected
+// to be valid JSON, and an error is returned when it ends too early or a
+// value cannot be read.
+func (d *document) parse() (*node, error) {
+	d.skipWhitespace()
+	if d.i >= len(d.data) {
+		return nil, errInvalidDocument
+	}
+	n := &node{kind: d.data[d.i], start: d.i}
+	switch n.kind {
+	case '{':
+		d.i++
+		for d.skipWhitespace(); d.i < len(d.data) && d.data[d.i] != '}'; d.skipWhitespace() {
+			if d.data[d.i] == ',' {
+				d.i++
+				d.skipWhitespace()
+			}
+			key, err := d.parse()
+			if err != nil {
+				return nil, err
+			}
+			var name string
+			_ = json.Unmarshal(d.data[key.start:key.end], &name)
+			d.skipWhitespace()
+			d.i++ // colon
+			value, err := d.parse()
+			if err != nil {
+				return nil, err
+			}
+			n.members = append(n.members, member{key: name, start: key.start, value: value})
+		}
+		if d.i >= len(d.data) {
+			return nil, errInvalidDocument
+		}
+		n.closing = d.i
+		d.i++
+	case '[':
+		d.i++
+		for d.skipWhitespace(); d.i < len(d.data) && d.data[d.i] != ']'; d.skipWhitespace() {
+			if d.data[d.i] == ',' {
+				d.i++
+				d.skipWhitespace()
+			}
+			item, err := d.parse()
+			if err != nil {
+				return nil, err
+			}
+			n.items = append(n.items, item)
+		}
+		if d.i >= len(d.data) {
+			return nil, errInvalidDocument
+		}
+		n.closing = d.i
+		d.i++
+	case '"':
+		for d.i++; d.i < len(d.data) && d.data[d.i] != '"'; d.i++ {
+			if d.data[d.i] == '\\' {
+				d.i++
+			}
+		}
+		if d.i >= len(d.data) {
+			return nil, errInvalidDocument
+		}
+		d.i++
+	default:
+		if n.kind == '-' || isDigit(rune(n.kind)) {
+			n.kind = '0'
+		}
+		for d.i < len(d.data) && strings.IndexByte(",]} \t\n\r", d.data[d.i]) == -1 {
+			d.i++
+		}
+		if d.i == n.start {
+			// A delimiter where a value was expected
+			return nil, errInvalidDocument
+		}
+	}
+	n.end = d.i
+	return n, nil
+}
+
+func (d *document) skipWhitespace() {
+	for d.i < len(d.data) && strings.IndexByte(" \t\n\r", d.data[d.i]) != -1 {
+		d.i++
+	}
+}
+
+// raw returns the text of a node in the document
+func (d *document) raw(n *node) string {
+	return string(d.data[n.start:n.end])
+}
+
+// record reports a coercion of the document text at output offset pos
+func (c *coercer) record(kind RepairKind, pos int, original, replacement string) {
+	c.coercions = append(c.coercions, Repair{
+		Kind:         kind,
+		InputOffset:  c.inputOffset(pos),
+		OutputOffset: pos,
+		Original:     original,
+		Replacement:  replacement,
+	})
+}
+
+// inputOffset maps an offset in the repaired document to the input, using
+// the syntactic repairs applied before it
+func (d *document) inputOffset(pos int) int {
+	delta := 0
+	for _, r := range d.repairs {
+		switch {
+		case r.OutputOffset+len(r.Replacement) <= pos:
+			delta += len(r.Replacement) - len(r.Original)
+		case r.OutputOffset <= pos:
+			return r.InputOffset
+		}
+	}
+	return pos - delta
+}
+
+var numberType = reflect.TypeOf(json.Number(""))
+
+// coerce writes node n to b, converted where needed to be decoded into a
+// value of type t
+func (c *coercer) coerce(b *bytes.Buffer, n *node, t reflect.Type) {
+	for t.Kind() == reflect.Pointer {
+		t = t.Elem()
+	}
+	if n.kind == 'n' || t.Kind() == reflect.Interface || t == numberType ||
+		reflect.PointerTo(t).Implements(jsonUnmarshalerType) ||
+		(n.kind == '"' && reflect.PointerTo(t).Implements(textUnmarshalerType)) {
+		c.write(b, n)
+		return
+	}
+
+	switch t.Kind() {
+	case reflect.Bool:
+		c.coerceBool(b, n)
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64,
+		reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64, reflect.Uintptr,
+		reflect.Float32, reflect.Float64:
+		c.coerceNumber(b, n, t)
+	case reflect.String:
+		c.coerceString(b, n)
+	case reflect.Slice, reflect.Array:
+		if t.Kind() == reflect.Slice && t.Elem().Kind() == reflect.Uint8 && n.kind == '"' {
+			// Base64 encoded bytes
+			c.write(b, n)
+			return
+		}
+		c.coerceArray(b, n, t)
+	case reflect.Map:
+		if n.kind != '{' {
+			c.write(b, n)
+			return
+		}
+		b.WriteByte('{')
+		for j, m := range n.members {
+			if j > 0 {
+				b.WriteByte(',')
+			}
+			b.Write(c.data[m.start:m.value.start])
+			c.coerce(b, m.value, t.Elem())
+		}
+		b.WriteByte('}')
+	case reflect.Struct:
+		c.coerceStruct(b, n, t)
+	default:
+		c.write(b, n)
+	}
+}
+
+// write writes node n to b without converting it
+func (c *coercer) write(b *bytes.Buffer, n *node) {
+	b.WriteString(c.raw(n))
+}
+
+// replace writes replacement to b in place of node n, and reports it
+func (c *coercer) replace(b *bytes.Buffer, n *node, replacement string) {
+	if !c.parser.allowed(RepairTypeCoercion) {
+		c.write(b, n)
+		return
+	}
+	c.record(RepairTypeCoercion, n.start, c.raw(n), replacement)
+	b.WriteString(replacement)
+}
+
+// unquote returns the trimmed contents of a string node
+func (d *document) unquote(n *node) string {
+	var s string
+	_ = json.Unmarshal(d.data[n.start:n.end], &s)
+	return strings.TrimSpace(s)
+}
+
+func (c *coercer) coerceBool(b *bytes.Buffer, n *node) {
+	if text, ok := c.boolValue(n); ok && n.kind != 't' && n.kind != 'f' {
+		c.replace(b, n, text)
+		return
+	}
+	c.write(b, n)
+}
+
+func (c *coercer) coerceNumber(b *bytes.Buffer, n *node, t reflect.Type) {
+	isFloat := t.Kind() == reflect.Float32 || t.Kind() == reflect.Float64
+	if text, ok := c.numberValue(n, !isFloat); ok && text != c.raw(n) {
+		c.replace(b, n, text)
+		return
+	}
+	c.write(b, n)
+}
+
+func (c *coercer) coerceString(b *bytes.Buffer, n *node) {
+	if text, ok := c.stringValue(n); ok && n.kind != '"' {
+		c.replace(b, n, text)
+		return
+	}
+	c.write(b, n)
+}
+
+// boolValue returns node n as a JSON boolean, converting strings like "yes"
+// and numbers
+func (d *document) boolValue(n *node) (string, bool) {
+	switch n.kind {
+	case 't', 'f':
+		return d.raw(n), true
+	case '"':
+		switch strings.ToLower(d.unquote(n)) {
+		case "true", "yes", "y", "on", "1":
+			return "true", true
+		case "false", "no", "n", "off", "0":
+			return "false", true
+		}
+	case '0':
+		if f, err := strconv.ParseFloat(d.raw(n), 64); err == nil {
+			return strconv.FormatBool(f != 0), true
+		}
+	}
+	return "", false
+}
+
+// numberValue returns node n as a JSON number, converting strings and
+// booleans. With integer, numbers with a fraction are not accepted.
+func (d *document) numberValue(n *node, integer bool) (string, bool) {
+	text := d.raw(n)
+	switch n.kind {
+	case 't':
+		return "1", true
+	case 'f':
+		return "0", true
+	case '"':
+		text = strings.ReplaceAll(d.unquote(n), "_", "")
+	case '0':
+	default:
+		return "", false
+	}
+
+	f, err := strconv.ParseFloat(text, 64)
+	if err != nil || math.IsInf(f, 0) || math.IsNaN(f) {
+		return "", false
+	}
+	switch {
+	case json.Valid([]byte(text)) && (!integer || !strings.ContainsAny(text, ".eE")):
+		// A valid JSON number already
+		return text, true
+	case !integer:
+		return strconv.FormatFloat(f, 'g', -1, 64), true
+	case f == math.Trunc(f) && math.Abs(f) < 1<<53:
+		return strconv.FormatFloat(f, 'f', -1, 64), true
+	}
+	return "", false
+}
+
+// stringValue returns node n as a JSON string, converting numbers and
+// booleans
+func (d *document) stringValue(n *node) (string, bool) {
+	switch n.kind {
+	case '"':
+		return d.raw(n), true
+	case '0', 't', 'f':
+		return strconv.Quote(d.raw(n)), true
+	}
+	return "", false
+}
+
+func (c *coercer) coerceArray(b *bytes.Buffer, n *node, t reflect.Type) {
+	if n.kind != '[' {
+		if !c.parser.allowed(RepairWrapArray) {
+			c.write(b, n)
+			return
+		}
+		c.record(RepairWrapArray, n.start, "", "[")
+		b.WriteByte('[')
+		c.coerce(b, n, t.Elem())
+		c.record(RepairWrapArray, n.end, "", "]")
+		b.WriteByte(']')
+		return
+	}
+
+	b.WriteByte('[')
+	for j, item := range n.items {
+		if j > 0 {
+			b.WriteByte(',')
+		}
+		c.coerce(b, item, t.Elem())
+	}
+	b.WriteByte(']')
+}
+
+func (c *coercer) coerceStruct(b *bytes.Buffer, n *node, t reflect.Type) {
+	if n.kind != '{' {
+		c.write(b, n)
+		return
+	}
+
+	fields := structFields(t)
+	found := make([]bool, len(fields))
+	b.WriteByte('{')
+	written := 0
+	for j, m := range n.members {
+		f := matchField(fields, m.key)
+		if f == -1 && c.parser.allowed(RepairUnknownField) {
+			end := m.value.end
+			if j+1 < len(n.members) {
+				end = n.members[j+1].start
+			}
+			c.record(RepairUnknownField, m.start, strings.TrimRight(string(c.data[m.start:end]), " \t\n\r,"), "")
+			continue
+		}
+		if written > 0 {
+			b.WriteByte(',')
+		}
+		written++
+		b.Write(c.data[m.start:m.value.start])
+		if f == -1 || fields[f].quoted {
+			c.write(b, m.value)
+			continue
+		}
+		found[f] = true
+		c.coerce(b, m.value, fields[f].typ)
+	}
+
+	for f, field := range fields {
+		if found[f] || !field.required || !c.parser.allowed(RepairMissingField) {
+			continue
+		}
+		zero := zeroJSON(field.typ)
+		if field.quoted {
+			zero = strconv.Quote(zero)
+		}
+		text := strconv.Quote(field.name) + ": " + zero
+		if written > 0 {
+			b.WriteByte(',')
+		}
+		written++
+		c.record(RepairMissingField, n.closing, "", text)
+		b.WriteString(text)
+	}
+	b.WriteByte('}')
+}
+
+// field is a struct field decoded by encoding/json
+type field struct {
+	name     string
+	typ      reflect.Type
+	required bool // Fields without omitempty are required
+	quoted   bool // Encoded as a string with the ,string option
+}
+
+// structFields lists the fields of struct type t the way encoding/json
+// decodes them, including the fields of embedded structs. Like
+// encoding/json, it visits each embedded struct type once, and of the
+// fields with the same name it keeps the one embedded least deep, a tagged
+// one when there are several, or none when that does not settle it.
+func structFields(t reflect.Type) []field {
+	// embedded is a struct whose fields are promoted into t
+	type embedded struct {
+		typ   reflect.Type
+		index []int
+	}
+	// candidate is a field of t or of a struct embedded in it
+	type candidate struct {
+		field
+		index  []int
+		tagged bool
+	}
+
+	var found []candidate
+	next := []embedded{{typ: t}}
+	visited := map[reflect.Type]bool{}
+	for len(next) > 0 {
+		current := next
+		next = nil
+		count := map[reflect.Type]int{}
+		for _, e := range current {
+			count[e.typ]++
+		}
+		for _, e := range current {
+			if visited[e.typ] {
+				continue
+			}
+			visited[e.typ] = true
+
+			for j := 0; j < e.typ.NumField(); j++ {
+				sf := e.typ.Field(j)
+				if sf.Anonymous {
+					ft := sf.Type
+					if ft.Kind() == reflect.Pointer {
+						ft = ft.Elem()
+					}
+					if !sf.IsExported() && ft.Kind() != reflect.Struct {
+						continue
+					}
+				} else if !sf.IsExported() {
+					continue
+				}
+				tag := sf.Tag.Get("json")
+				if tag == "-" {
+					continue
+				}
+				name, opts, _ := strings.Cut(tag, ",")
+				index := append(slices.Clone(e.index), j)
+
+				ft := sf.Type
+				if ft.Name() == "" && ft.Kind() == reflect.Pointer {
+					ft = ft.Elem()
+				}
+				if name == "" && sf.Anonymous && ft.Kind() == reflect.Struct {
+					next = append(next, embedded{typ: ft, index: index})
+					continue
+				}
+
+				c := candidate{field: field{name: name, typ: sf.Type}, index: index, tagged: name != ""}
+				if c.name == "" {
+					c.name = sf.Name
+				}
+				omitempty := false
+				for _, opt := range strings.Split(opts, ",") {
+					switch opt {
+					case "omitempty", "omitzero":
+						omitempty = true
+					case "string":
+						c.quoted = true
+					}
+				}
+				c.required = !omitempty
+				found = append(found, c)
+				if count[e.typ] > 1 {
+					// Embedded twice at the same depth, so its fields
+					// conflict and are dropped below
+					found = append(found, c)
+				}
+			}
+		}
+	}
+
+	// Keep the dominant field of each name, at the least depth
+	slices.SortStableFunc(found, func(a, b candidate) int {
+		if c := strings.Compare(a.name, b.name); c != 0 {
+			return c
+		}
+		if c := len(a.index) - len(b.index); c != 0 {
+			return c
+		}
+		if a.tagged != b.tagged {
+			if a.tagged {
+				return -1
+			}
+			return 1
+		}
+		return 0
+	})
+	var kept []candidate
+	for j := 0; j < len(found); {
+		k := j + 1
+		for k < len(found) && found[k].name == found[j].name {
+			k++
+		}
+		first := found[j]
+		if k == j+1 || len(found[j+1].index) > len(first.index) || first.tagged && !found[j+1].tagged {
+			kept = append(kept, first)
+		}
+		j = k
+	}
+
+	// In the order of the fields in t
+	slices.SortFunc(kept, func(a, b candidate) int {
+		return slices.Compare(a.index, b.index)
+	})
+	fields := make([]field, len(kept))
+	for j, c := range kept {
+		fields[j] = c.field
+	}
+	return fields
+}
+
+// matchField returns the index of the field named key, preferring an exact
+// match over a case-insensitive one like encoding/json, or -1
+func matchField(fields []field, key string) int {
+	match := -1
+	for j, f := range fields {
+		if f.name == key {
+			return j
+		}
+		if match == -1 && strings.EqualFold(f.name, key) {
+			match = j
+		}
+	}
+	return match
+}
+
+// zeroJSON returns the JSON encoding of the zero value of type t
+func zeroJSON(t reflect.Type) string {
+	switch t.Kind() {
+	case reflect.Pointer, reflect.Interface, reflect.Slice, reflect.Map:
+		return "null"
+	}
+	data, err := json.Marshal(reflect.Zero(t).Interface())
+	if err != nil {
+		return "null"
+	}
+	return string(data)
+}
@@ -0,0 +1,214 @@
+package jsonrepair
+
+import (
+	"encoding/json"
+	"errors"
+	"reflect"
+	"testing"
+	"time"
+)
+
+type coerceItem struct {
+	ID    int     `json:"id"`
+	Price float64 `json:"price,omitempty"`
+}
+
+type coerceOrder struct {
+	Name    string            `json:"name"`
+	Count   int               `json:"count"`
+	Paid    bool              `json:"paid"`
+	Tags    []string          `json:"tags,omitempty"`
+	Items   []coerceItem      `json:"items,omitempty"`
+	Note    *string           `json:"note"`
+	Created time.Time         `json:"created,omitempty"`
+	Extra   map[string]uint16 `json:"extra,omitempty"`
+}
+
+func TestRepairInto(t *testing.T) {
+	input := `{name: 123, count: "5", paid: "yes", tags: 'urgent', items: [{id: "1", price: "2.50"}, {id: 2.0}],
+		created: "2024-01-02T03:04:05Z", extra: {a: "7"}, unknown: [1, 2], }`
+	order, repairs, err := RepairInto[coerceOrder](input)
+	if err != nil {
+		t.Fatalf("RepairInto returned error: %v", err)
+	}
+
+	expected := coerceOrder{
+		Name:    "123",
+		Count:   5,
+		Paid:    true,
+		Tags:    []string{"urgent"},
+		Items:   []coerceItem{{ID: 1, Price: 2.5}, {ID: 2}},
+		Created: time.Date(2024, 1, 2, 3, 4, 5, 0, time.UTC),
+		Extra:   map[string]uint16{"a": 7},
+	}
+	if !reflect.DeepEqual(order, expected) {
+		t.Errorf("RepairInto = %+v, want %+v", order, expected)
+	}
+
+	var kinds []RepairKind
+	for _, r := range repairs {
+		if r.Kind >= RepairTypeCoercion {
+			kinds = append(kinds, r.Kind)
+		}
+	}
+	expectedKinds := []RepairKind{
+		RepairTypeCoercion, // name
+		RepairTypeCoercion, // count
+		RepairTypeCoercion, // paid
+		RepairWrapArray,    // tags
+		RepairWrapArray,
+		RepairTypeCoercion, // items[0].id
+		RepairTypeCoercion, // items[0].price
+		RepairTypeCoercion, // items[1].id
+		RepairTypeCoercion, // extra.a
+		RepairUnknownField, // unknown
+		RepairMissingField, // note
+	}
+	if !reflect.DeepEqual(kinds, expectedKinds) {
+		t.Errorf("RepairInto repair kinds = %v, want %v", kinds, expectedKinds)
+	}
+}
+
+func TestRepairIntoReport(t *testing.T) {
+	_, repairs, err := RepairInto[coerceItem](`{'id': "5", 'x': 1}`)
+	if err != nil {
+		t.Fatalf("RepairInto returned error: %v", err)
+	}
+	expected := []Repair{
+		{RepairQuotes, 1, 1, "'", `"`},
+		{RepairQuotes, 4, 4, "'", `"`},
+		{RepairTypeCoercion, 7, 7, `"5"`, "5"},
+		{RepairQuotes, 12, 12, "'", `"`},
+		{RepairUnknownField, 12, 12, `"x": 1`, ""},
+		{RepairQuotes, 14, 14, "'", `"`},
+	}
+	if !reflect.DeepEqual(repairs, expected) {
+		t.Errorf("RepairInto repairs = %v, want %v", repairs, expected)
+	}
+}
+
+func TestRepairIntoScalars(t *testing.T) {
+	count, _, err := RepairInto[int](`"1_000"`)
+	if err != nil || count != 1000 {
+		t.Errorf("RepairInto[int] = %v, %v", count, err)
+	}
+
+	enabled, _, err := RepairInto[bool](`0`)
+	if err != nil || enabled {
+		t.Errorf("RepairInto[bool] = %v, %v", enabled, err)
+	}
+
+	values, _, err := RepairInto[[]float64](`['.5', 1e3, true]`)
+	if err != nil || !reflect.DeepEqual(values, []float64{0.5, 1000, 1}) {
+		t.Errorf("RepairInto[[]float64] = %v, %v", values, err)
+	}
+
+	value, repairs, err := RepairInto[any](`{a: "5"}`)
+	if err != nil || !reflect.DeepEqual(value, map[string]any{"a": "5"}) || len(repairs) != 1 {
+		t.Errorf("RepairInto[any] = %v, %v, %v", value, repairs, err)
+	}
+
+	pointer, _, err := RepairInto[*coerceItem](`{"id": "3"}`)
+	if err != nil || pointer == nil || pointer.ID != 3 {
+		t.Errorf("RepairInto[*coerceItem] = %v, %v", pointer, err)
+	}
+}
+
+type coerceNode struct {
+	*coerceNode
+	Value int `json:"value"`
+}
+
+type coerceBase struct {
+	ID   int    `json:"id"`
+	Name string `json:"name"`
+	Size int    `json:"size"`
+}
+
+type coerceLabel struct {
+	Name string `json:"name"`
+}
+
+type coerceEmbedding struct {
+	coerceBase
+	*coerceLabel
+	ID string `json:"id"`
+}
+
+func TestRepairIntoEmbedded(t *testing.T) {
+	// A struct embedding a pointer to itself has the fields of one level
+	node, _, err := RepairInto[coerceNode](`{value: "1"}`)
+	if err != nil || node.Value != 1 || node.coerceNode != nil {
+		t.Errorf("RepairInto[coerceNode] = %+v, %v", node, err)
+	}
+
+	// The outer id dominates the embedded one, and name is ambiguous, so
+	// encoding/json ignores it
+	var names []string
+	for _, f := range structFields(reflect.TypeOf(coerceEmbedding{})) {
+		names = append(names, f.name)
+	}
+	if !reflect.DeepEqual(names, []string{"size", "id"}) {
+		t.Errorf("structFields(coerceEmbedding) = %v, want [size id]", names)
+	}
+	value, repairs, err := RepairInto[coerceEmbedding](`{id: 5, name: "x", size: 2}`)
+	expected := coerceEmbedding{coerceBase: coerceBase{Size: 2}, ID: "5"}
+	if err != nil || !reflect.DeepEqual(value, expected) {
+		t.Errorf("RepairInto[coerceEmbedding] = %+v, %v, want %+v", value, err, expected)
+	}
+	var kinds []RepairKind
+	for _, r := range repairs {
+		if r.Kind >= RepairTypeCoercion {
+			kinds = append(kinds, r.Kind)
+		}
+	}
+	if !reflect.DeepEqual(kinds, []RepairKind{RepairTypeCoercion, RepairUnknownField}) {
+		t.Errorf("RepairInto[coerceEmbedding] repair kinds = %v", kinds)
+	}
+}
+
+func TestRepairIntoErrors(t *testing.T) {
+	_, _, err := RepairInto[coerceItem](`{"id": "five"}`)
+	var decodeErr *DecodeError
+	if !errors.As(err, &decodeErr) || decodeErr.Path != "$.id" {
+		t.Errorf("RepairInto error = %v, want a *DecodeError at $.id", err)
+	}
+
+	_, _, err = RepairInto[coerceItem](`{"id": 1.5}`)
+	if !errors.As(err, &decodeErr) {
+		t.Errorf("RepairInto error = %v, want a *DecodeError", err)
+	}
+
+	_, _, err = RepairIntoWithOptions[coerceItem](`{"id": "1"}`, Options{Disable: []RepairKind{RepairTypeCoercion}})
+	if !errors.As(err, &decodeErr) {
+		t.Errorf("RepairIntoWithOptions error = %v, want a *DecodeError", err)
+	}
+
+	_, _, err = RepairInto[coerceItem](`{"id": 1} x`)
+	if !errors.Is(err, ErrUnexpectedCharacter) {
+		t.Errorf("RepairInto error = %v, want ErrUnexpectedCharacter", err)
+	}
+}
+
+func TestRepairIntoInvalidOutput(t *testing.T) {
+	// These are repaired without an error into output that is not valid JSON
+	for _, input := range []string{`[1,{,2}]`, `{undefined`, `{*/`} {
+		if output, err := JSONRepair(input); err != nil || json.Valid([]byte(output)) {
+			t.Fatalf("JSONRepair(%q) = %q, %v, want output that is not valid JSON", input, output, err)
+		}
+		_, _, err := RepairInto[map[string]any](input)
+		var decodeErr *DecodeError
+		if !errors.As(err, &decodeErr) {
+			t.Errorf("RepairInto(%q) error = %v, want a *DecodeError", input, err)
+		}
+	}
+}
+
+func TestDocumentParseInvalid(t *testing.T) {
+	for _, data := range []string{``, ` `, `{"a": 1`, `{"a"`, `["a"`, `"abc`, `"abc\`, `[,]`, `{"a":}`} {
+		d := document{data: []byte(data)}
+		if _, err := d.parse(); err == nil {
+			t.Errorf("document.parse(%q) succeeded", data)
+		}
+	}
+}
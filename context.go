@@ -0,0 +1,62 @@
+package jsonrepair
+
+import "context"
+
+// contextCheckInterval is the number of loop iterations of the parser
+// between two checks of its context
+const contextCheckInterval = 1024
+
+// RepairContext is like JSONRepair, but stops repairing once ctx is
+// canceled or its deadline is exceeded. The error is then a
+// *JSONRepairError with CodeCanceled, which wraps the error of ctx:
+//
+//	ctx, cancel := context.WithTimeout(r.Context(), 100*time.Millisecond)
+//	defer cancel()
+//	repaired, err := jsonrepair.RepairContext(ctx, body)
+//	if errors.Is(err, context.DeadlineExceeded) {
+//		// reject the request
+//	}
+func RepairContext(ctx context.Context, text string) (string, error) {
+	return RepairContextWithOptions(ctx, text, Options{})
+}
+
+// RepairContextWithOptions is like RepairContext, applying only the
+// repairs allowed by opts
+func RepairContextWithOptions(ctx context.Context, text string, opts Options) (string, error) {
+	parser := NewParserWithOptions(text, opts)
+	if err := ctx.Err(); err != nil {
+		return "", parser.canceled(err)
+	}
+	parser.ctx = ctx
+	return parser.Parse()
+}
+
+// checkContext aborts parsing once the context of the parser is done. It
+// is called in the loops of the parser and only looks at the context every
+// contextCheckInterval calls.
+func (p *Parser) checkContext() {
+	if p.ctx == nil {
+		return
+	}
+	if p.steps++; p.steps < contextCheckInterval {
+		return
+	}
+	p.pollContext()
+}
+
+// pollContext aborts parsing when the context of the parser is done. It is
+// kept apart from checkContext so that checkContext is inlined.
+func (p *Parser) pollContext() {
+	p.steps = 0
+	if err := p.ctx.Err(); err != nil {
+		p.fail(p.canceled(err))
+	}
+}
+
+// canceled returns the error for a parse stopped by the error err of its
+// context
+func (p *Parser) canceled(err error) *JSONRepairError {
+	e := p.newError(CodeCanceled, "Repair canceled: "+err.Error(), p.offset+p.i)
+	e.Err = err
+	return e
+}
@@ -0,0 +1,72 @@
+package jsonrepair
+
+import (
+	"context"
+	"errors"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestRepairContext(t *testing.T) {
+	input := `{name: 'John', tags: ['a', 'b',], "nested": {"a": [1, 2`
+	expected, _ := JSONRepair(input)
+	result, err := RepairContext(context.Background(), input)
+	if err != nil || result != expected {
+		t.Errorf("RepairContext(%q) = %q, %v, want %q", input, result, err, expected)
+	}
+
+	result, err = RepairContextWithOptions(context.Background(), input, Options{Format: FormatCompact})
+	if err != nil || result != `{"name":"John","tags":["a","b"],"nested":{"a":[1,2]}}` {
+		t.Errorf("RepairContextWithOptions(%q) = %q, %v", input, result, err)
+	}
+}
+
+func TestRepairContextCanceled(t *testing.T) {
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+
+	_, err := RepairContext(ctx, `[1, 2, 3]`)
+	var repairErr *JSONRepairError
+	if !errors.As(err, &repairErr) || repairErr.Code != CodeCanceled {
+		t.Fatalf("Expected a canceled repair error, got %v", err)
+	}
+	if !errors.Is(err, context.Canceled) || !errors.Is(err, ErrCanceled) {
+		t.Errorf("Error %v does not match context.Canceled and ErrCanceled", err)
+	}
+}
+
+// expiringContext is a context whose deadline is exceeded once its error
+// has been checked a given number of times
+type expiringContext struct {
+	context.Context
+	checks int
+}
+
+func (c *expiringContext) Err() error {
+	if c.checks--; c.checks < 0 {
+		return context.DeadlineExceeded
+	}
+	return nil
+}
+
+func TestRepairContextDeadline(t *testing.T) {
+	ctx, cancel := context.WithDeadline(context.Background(), time.Now().Add(-time.Second))
+	defer cancel()
+	_, err := RepairContext(ctx, `[1, 2, 3]`)
+	if !errors.Is(err, context.DeadlineExceeded) || !errors.Is(err, ErrCanceled) {
+		t.Fatalf("Expected the deadline to be exceeded, got %v", err)
+	}
+
+	// The deadline is exceeded at the first check while parsing, which
+	// stops the repair in the middle of the input
+	input := "[" + strings.Repeat("'a', ", 100000) + "]"
+	_, err = RepairContext(&expiringContext{Context: context.Background(), checks: 1}, input)
+	var repairErr *JSONRepairError
+	if !errors.As(err, &repairErr) || !errors.Is(err, context.DeadlineExceeded) {
+		t.Fatalf("Expected the deadline to be exceeded, got %v", err)
+	}
+	if repairErr.Position == 0 || repairErr.Position >= len(input)/2 {
+		t.Errorf("Repair stopped at %d of %d", repairErr.Position, len(input))
+	}
+}
@@ -0,0 +1,274 @@
+package jsonrepair
+
+import (
+	"encoding/json"
+	"fmt"
+	"slices"
+	"strings"
+)
+
+// duplicateResolver resolves the duplicate keys of a repaired document
+// according to a DuplicateKeys policy
+type duplicateResolver struct {
+	document
+	parser *Parser
+	policy DuplicateKeys
+	keys   map[int]int // Input offset of each key by its offset in the document
+	edits  []duplicateEdit
+}
+
+// duplicateEdit is an edit resolving a duplicate key
+type duplicateEdit struct {
+	edit
+	input int // Input offset of the key the edit is reported at
+}
+
+// resolveDuplicates resolves the duplicate keys of the output and records
+// a repair for each resolution. Output that is still not valid JSON, which
+// some input is repaired into, is left as it is.
+func (p *Parser) resolveDuplicates() error {
+	output := []byte(p.output.String())
+	if !json.Valid(output) {
+		return nil
+	}
+	r := duplicateResolver{document: document{data: output, repairs: p.repairs}, parser: p, policy: p.duplicates}
+	root, err := r.parse()
+	if err != nil {
+		return err
+	}
+	r.keys = make(map[int]int)
+	r.index(root, slices.Clone(p.keys))
+	if err := r.resolve(root, 0, "$"); err != nil {
+		return err
+	}
+	r.sort()
+
+	// Apply the edits from the end, so that the offsets of the earlier ones
+	// and of the repairs recorded so far remain valid
+	for j := len(r.edits) - 1; j >= 0; j-- {
+		e := r.edits[j]
+		p.output.replace(e.start, e.end-e.start, e.text)
+		p.record(Repair{
+			Kind:         RepairDuplicateKey,
+			InputOffset:  e.input,
+			OutputOffset: e.start,
+			Original:     string(r.data[e.start:e.end]),
+			Replacement:  e.text,
+		})
+	}
+	return nil
+}
+
+// index maps the keys of the document to the input offsets recorded by the
+// parser, which are in the same order. Keys the parser did not record are
+// mapped using the repairs instead.
+func (r *duplicateResolver) index(n *node, keys []int) []int {
+	for _, m := range n.members {
+		if len(keys) > 0 {
+			r.keys[m.start], keys = keys[0], keys[1:]
+		} else {
+			r.keys[m.start] = r.inputOffset(m.start)
+		}
+		keys = r.index(m.value, keys)
+	}
+	for _, item := range n.items {
+		keys = r.index(item, keys)
+	}
+	return keys
+}
+
+// edit replaces the bytes start to end of the document by text, reported
+// at the input offset of the key at offset key
+func (r *duplicateResolver) edit(start, end int, text string, key int) {
+	r.edits = append(r.edits, duplicateEdit{edit{kind: RepairDuplicateKey, start: start, end: end, text: text}, r.keys[key]})
+}
+
+// sort orders the edits by their offset in the document
+func (r *duplicateResolver) sort() {
+	slices.SortStableFunc(r.edits, func(a, b duplicateEdit) int { return a.start - b.start })
+}
+
+// resolve resolves the duplicate keys of node n and its descendants. depth
+// is the nesting depth of n and path its JSON path.
+func (r *duplicateResolver) resolve(n *node, depth int, path string) error {
+	for j, item := range n.items {
+		if err := r.resolve(item, depth+1, fmt.Sprintf("%s[%d]", path, j)); err != nil {
+			return err
+		}
+	}
+	if n.kind != '{' {
+		return nil
+	}
+
+	// Group the members by key, in the order of their first appearance
+	var order []string
+	groups := make(map[string][]int)
+	for j, m := range n.members {
+		if _, ok := groups[m.key]; !ok {
+			order = append(order, m.key)
+		} else if r.policy == DuplicateKeysError {
+			err := r.parser.newError(CodeDuplicateKey, fmt.Sprintf("Duplicate key %q", m.key), r.keys[m.start])
+			err.Path = path
+			return err
+		}
+		groups[m.key] = append(groups[m.key], j)
+	}
+
+	keep := make([]bool, len(n.members))
+	for _, key := range order {
+		group := groups[key]
+		var b strings.Builder
+		b.WriteString(path)
+		writePathKey(&b, key)
+		child := b.String()
+
+		if len(group) == 1 {
+			keep[group[0]] = true
+			if err := r.resolve(n.members[group[0]].value, depth+1, child); err != nil {
+				return err
+			}
+			continue
+		}
+
+		kept := group[0]
+		if r.policy == DuplicateKeysLast {
+			kept = group[len(group)-1]
+		}
+		keep[kept] = true
+		m := n.members[kept]
+		switch r.policy {
+		case DuplicateKeysMerge, DuplicateKeysArray:
+			values := make([]*node, len(group))
+			for k, j := range group {
+				values[k] = n.members[j].value
+			}
+			text, err := r.combine(values, depth)
+			if err != nil {
+				return err
+			}
+			r.edit(m.value.start, m.value.end, text, m.start)
+		default:
+			if err := r.resolve(m.value, depth+1, child); err != nil {
+				return err
+			}
+		}
+	}
+
+	// Remove each other member with the comma separating it from the
+	// previous member, or from the next one when it is the first
+	last := -1
+	for j, m := range n.members {
+		switch {
+		case keep[j]:
+			last = j
+		case last != -1:
+			r.edit(n.members[j-1].value.end, m.value.end, "", m.start)
+		default:
+			r.edit(m.start, n.members[j+1].start, "", m.start)
+		}
+	}
+	return nil
+}
+
+// combine returns the value replacing the values of a duplicate key, which
+// are members of an object at the given depth
+func (r *duplicateResolver) combine(values []*node, depth int) (string, error) {
+	if r.policy == DuplicateKeysArray {
+		texts := make([]string, len(values))
+		for j, v := range values {
+			text, err := r.text(v, depth+1)
+			if err != nil {
+				return "", err
+			}
+			texts[j] = text
+		}
+		return r.array(texts, depth+1), nil
+	}
+
+	// Merge objects by moving the members of the later ones into the first
+	// one that has members, and resolving the duplicates this makes
+	var base *node
+	var members []string
+	for _, v := range values {
+		if v.kind != '{' {
+			return r.text(values[len(values)-1], depth+1)
+		}
+		for _, m := range v.members {
+			if base == nil {
+				base = v
+			} else if v != base {
+				members = append(members, string(r.data[m.start:m.value.end]))
+			}
+		}
+	}
+	if base == nil {
+		return "{}", nil
+	}
+
+	end := base.members[len(base.members)-1].value.end
+	var b strings.Builder
+	b.Write(r.data[base.start:end])
+	for _, m := range members {
+		b.WriteString(r.separator(depth + 2))
+		b.WriteString(m)
+	}
+	b.Write(r.data[end:base.end])
+
+	merged := duplicateResolver{document: document{data: []byte(b.String())}, parser: r.parser, policy: r.policy}
+	root, err := merged.parse()
+	if err != nil {
+		return "", err
+	}
+	merged.keys = make(map[int]int)
+	merged.index(root, nil)
+	return merged.apply(root, depth+1)
+}
+
+// text returns the text of node n at the given depth with its duplicate
+// keys resolved
+func (r *duplicateResolver) text(n *node, depth int) (string, error) {
+	sub := duplicateResolver{document: r.document, parser: r.parser, policy: r.policy, keys: r.keys}
+	return sub.apply(n, depth)
+}
+
+// apply resolves the duplicate keys of node n and returns its text with
+// the edits applied
+func (r *duplicateResolver) apply(n *node, depth int) (string, error) {
+	if err := r.resolve(n, depth, "$"); err != nil {
+		return "", err
+	}
+	r.sort()
+
+	var b strings.Builder
+	last := n.start
+	for _, e := range r.edits {
+		b.Write(r.data[last:e.start])
+		b.WriteString(e.text)
+		last = e.end
+	}
+	b.Write(r.data[last:n.end])
+	return b.String(), nil
+}
+
+// array returns an array of the values, which are at the given depth
+func (r *duplicateResolver) array(values []string, depth int) string {
+	if r.parser.format != FormatIndent {
+		return "[" + strings.Join(values, r.separator(depth+1)) + "]"
+	}
+	indent := "\n" + strings.Repeat(r.parser.indent, depth+1)
+	for j, v := range values {
+		values[j] = strings.ReplaceAll(v, "\n", "\n"+r.parser.indent)
+	}
+	return "[" + indent + strings.Join(values, ","+indent) + "\n" + strings.Repeat(r.parser.indent, depth) + "]"
+}
+
+// separator returns the text separating two members at the given depth
+func (r *duplicateResolver) separator(depth int) string {
+	switch r.parser.format {
+	case FormatPreserve:
+		return ", "
+	case FormatIndent:
+		return ",\n" + strings.Repeat(r.parser.indent, depth)
+	}
+	return ","
+}
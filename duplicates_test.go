@@ -0,0 +1,149 @@
+package jsonrepair
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"strings"
+	"testing"
+	"testing/iotest"
+)
+
+func TestDuplicateKeys(t *testing.T) {
+	tests := []struct {
+		name     string
+		input    string
+		opts     Options
+		expected string
+	}{
+		{"allow", `{"a": 1, "a": 2}`, Options{}, `{"a": 1, "a": 2}`},
+		{"first", `{"a": 1, "b": 2, "a": 3}`, Options{DuplicateKeys: DuplicateKeysFirst}, `{"a": 1, "b": 2}`},
+		{"last", `{"a": 1, "b": 2, "a": 3}`, Options{DuplicateKeys: DuplicateKeysLast}, `{"b": 2, "a": 3}`},
+		{"merge", `{"a": 1, "b": 2, "a": 3}`, Options{DuplicateKeys: DuplicateKeysMerge}, `{"a": 3, "b": 2}`},
+		{"array", `{"a": 1, "b": 2, "a": 3}`, Options{DuplicateKeys: DuplicateKeysArray}, `{"a": [1, 3], "b": 2}`},
+		{"first of three", `{"a": 1, "a": 2, "a": 3}`, Options{DuplicateKeys: DuplicateKeysFirst}, `{"a": 1}`},
+		{"last of three", `{"a": 1, "a": 2, "a": 3}`, Options{DuplicateKeys: DuplicateKeysLast}, `{"a": 3}`},
+		{"array of three", `{"a": 1, "a": 2, "a": 3}`, Options{DuplicateKeys: DuplicateKeysArray}, `{"a": [1, 2, 3]}`},
+		{"merge objects recursively",
+			`{"a": {"x": 1, "y": {"p": 1}}, "b": 2, "a": {"y": {"q": 2}, "z": 3}}`,
+			Options{DuplicateKeys: DuplicateKeysMerge},
+			`{"a": {"x": 1, "y": {"p": 1, "q": 2}, "z": 3}, "b": 2}`},
+		{"merge into empty object", `{"a": {}, "a": {"x": 1}, "a": {}}`, Options{DuplicateKeys: DuplicateKeysMerge}, `{"a": {"x": 1}}`},
+		{"merge object and scalar", `{"a": {"x": 1}, "a": null}`, Options{DuplicateKeys: DuplicateKeysMerge}, `{"a": null}`},
+		{"nested", `[{"a": {"b": 1, "b": 2}}, {"c": 1, "c": 2}]`, Options{DuplicateKeys: DuplicateKeysLast}, `[{"a": {"b": 2}}, {"c": 2}]`},
+		{"inside array values", `{"a": {"b": 1, "b": 2}, "a": 3}`, Options{DuplicateKeys: DuplicateKeysArray}, `{"a": [{"b": [1, 2]}, 3]}`},
+		{"escaped key", `{"a": 1, "\u0061": 2}`, Options{DuplicateKeys: DuplicateKeysFirst}, `{"a": 1}`},
+		{"repaired keys", `{a: 1, 'a': 2, b: [1, 2,]}`, Options{DuplicateKeys: DuplicateKeysLast}, `{"a": 2, "b": [1, 2]}`},
+		{"newline delimited", "{\"a\": 1, \"a\": 2}\n{\"a\": 3}", Options{DuplicateKeys: DuplicateKeysFirst}, "[\n{\"a\": 1},\n{\"a\": 3}\n]"},
+		{"compact", `{"a": 1, "b": 2, "a": 3}`, Options{DuplicateKeys: DuplicateKeysArray, Format: FormatCompact}, `{"a":[1,3],"b":2}`},
+		{"compact merge", `{"a": {"x": 1}, "a": {"y": 2}}`, Options{DuplicateKeys: DuplicateKeysMerge, Format: FormatCompact}, `{"a":{"x":1,"y":2}}`},
+		{"indent array", `{"a": {"x": 1}, "a": 2}`, Options{DuplicateKeys: DuplicateKeysArray, Format: FormatIndent},
+			"{\n  \"a\": [\n    {\n      \"x\": 1\n    },\n    2\n  ]\n}"},
+		{"indent merge", `{"a": {"x": 1}, "b": 2, "a": {"y": [2]}}`, Options{DuplicateKeys: DuplicateKeysMerge, Format: FormatIndent},
+			"{\n  \"a\": {\n    \"x\": 1,\n    \"y\": [\n      2\n    ]\n  },\n  \"b\": 2\n}"},
+		{"canonical", `{"b": 1, "a": 2, "b": 3}`, Options{DuplicateKeys: DuplicateKeysLast, Format: FormatCanonical}, `{"a":2,"b":3}`},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			result, err := JSONRepairWithOptions(tt.input, tt.opts)
+			if err != nil {
+				t.Fatalf("JSONRepairWithOptions(%q) error: %v", tt.input, err)
+			}
+			if result != tt.expected {
+				t.Errorf("JSONRepairWithOptions(%q) = %q, want %q", tt.input, result, tt.expected)
+			}
+
+			var out bytes.Buffer
+			err = RepairStreamWithOptions(iotest.OneByteReader(strings.NewReader(tt.input)), &out, tt.opts)
+			if err != nil || out.String() != tt.expected {
+				t.Errorf("RepairStreamWithOptions(%q) = %q, %v, want %q", tt.input, out.String(), err, tt.expected)
+			}
+		})
+	}
+}
+
+func TestDuplicateKeysReport(t *testing.T) {
+	input := `{a: 1, "b": 2, 'a': 3}`
+	result, err := JSONRepairWithReport(input, Options{DuplicateKeys: DuplicateKeysArray})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if result.Output != `{"a": [1, 3], "b": 2}` {
+		t.Errorf("Unexpected output %q", result.Output)
+	}
+	expected := []Repair{
+		{RepairUnquotedString, 1, 1, "a", `"a"`},
+		{RepairDuplicateKey, 1, 6, "1", "[1, 3]"},
+		{RepairDuplicateKey, 15, 20, `, "a": 3`, ""},
+	}
+	if len(result.Repairs) != len(expected) {
+		t.Fatalf("Repairs = %+v, want %+v", result.Repairs, expected)
+	}
+	for j, r := range result.Repairs {
+		if r != expected[j] {
+			t.Errorf("Repair %d = %+v, want %+v", j, r, expected[j])
+		}
+	}
+}
+
+func TestDuplicateKeysError(t *testing.T) {
+	input := "{\n  \"items\": [{\"id\": 1, \"name\": \"a\", \"id\": 2}]\n}"
+	_, err := JSONRepairWithOptions(input, Options{DuplicateKeys: DuplicateKeysError})
+	var repairErr *JSONRepairError
+	if !errors.As(err, &repairErr) || !errors.Is(err, ErrDuplicateKey) {
+		t.Fatalf("Expected a duplicate key error, got %v", err)
+	}
+	if repairErr.Message != `Duplicate key "id"` || repairErr.Position != 37 {
+		t.Errorf("Unexpected error %v", repairErr)
+	}
+	if repairErr.Line != 2 || repairErr.Column != 36 || repairErr.Path != "$.items[0]" {
+		t.Errorf("Unexpected location %d:%d %s", repairErr.Line, repairErr.Column, repairErr.Path)
+	}
+
+	var out bytes.Buffer
+	err = RepairStreamWithOptions(strings.NewReader(input), &out, Options{DuplicateKeys: DuplicateKeysError})
+	if !errors.Is(err, ErrDuplicateKey) || out.Len() > 0 {
+		t.Errorf("RepairStreamWithOptions wrote %q, error %v", out.String(), err)
+	}
+
+	if _, err := JSONRepairWithOptions(`{"a": {"a": 1}, "b": {"a": 2}}`, Options{DuplicateKeys: DuplicateKeysError}); err != nil {
+		t.Errorf("Unexpected error for keys in different objects: %v", err)
+	}
+}
+
+func TestDuplicateKeysRepairer(t *testing.T) {
+	input := `{"items": [{"id": 1, "id": 2}, {"id": 3}], "items": {"id": 4, "tags": ["a", "b"], "id": 5}}`
+	for _, policy := range []DuplicateKeys{DuplicateKeysFirst, DuplicateKeysLast, DuplicateKeysMerge, DuplicateKeysArray, DuplicateKeysError} {
+		opts := Options{DuplicateKeys: policy}
+		r := NewRepairerWithOptions(opts)
+		for i := 0; i < len(input); i++ {
+			r.WriteString(input[i : i+1])
+			prefix := input[:i+1]
+
+			expected, expectedErr := JSONRepairWithOptions(prefix, opts)
+			result, err := r.Snapshot()
+			if (err == nil) != (expectedErr == nil) || result != expected {
+				t.Fatalf("Snapshot after %q with policy %d = %q, %v, want %q, %v", prefix, policy, result, err, expected, expectedErr)
+			}
+		}
+	}
+}
+
+func TestDuplicateKeysInvalidOutput(t *testing.T) {
+	// These are repaired without an error into output that is not valid
+	// JSON, which is left as it is by every policy
+	policies := []DuplicateKeys{DuplicateKeysFirst, DuplicateKeysLast, DuplicateKeysMerge, DuplicateKeysArray, DuplicateKeysError}
+	for _, input := range []string{`[1,{,2}]`, `{undefined`, `{*/`} {
+		expected, err := JSONRepair(input)
+		if err != nil || json.Valid([]byte(expected)) {
+			t.Fatalf("JSONRepair(%q) = %q, %v, want output that is not valid JSON", input, expected, err)
+		}
+		for _, policy := range policies {
+			result, err := JSONRepairWithOptions(input, Options{DuplicateKeys: policy})
+			if err != nil || result != expected {
+				t.Errorf("JSONRepairWithOptions(%q, %v) = %q, %v, want %q", input, policy, result, err, expected)
+			}
+		}
+	}
+}
@@ -0,0 +1,211 @@
+package jsonrepair
+
+import (
+	"encoding/json"
+	"errors"
+	"regexp"
+	"strconv"
+	"strings"
+	"unicode/utf8"
+)
+
+// Code identifies the reason why a document could not be repaired
+type Code int
+
+const (
+	CodeUnexpectedEnd       Code = iota + 1 // The input ended where a value was required
+	CodeUnexpectedCharacter                 // A character that cannot be repaired was found
+	CodeInvalidCharacter                    // A string contains a character that is not allowed
+	CodeInvalidUnicode                      // A string contains an invalid \u escape sequence
+	CodeDepthExceeded                       // Objects and arrays are nested too deeply
+	CodeRepairDisabled                      // The input needs a repair that was disabled
+	CodeOutputFlushed                       // A repair needs output that was already written
+	CodeNotCanonical                        // The repaired document has no canonical form
+	CodeDuplicateKey                        // An object has a key more than once
+	CodeInputTooLarge                       // The input exceeds Options.MaxInputBytes
+	CodeOutputTooLarge                      // The output exceeds Options.MaxOutputBytes
+	CodeStringTooLong                       // A string exceeds Options.MaxStringLength
+	CodeCanceled                            // The context was canceled or its deadline exceeded
+	CodeNoJSON                              // No JSON object or array was found in the text
+	CodeNonFiniteNumber                     // NaN or Infinity was found with NonFiniteError
+)
+
+// Errors matching a *JSONRepairError with the corresponding Code, for use
+// with errors.Is:
+//
+//	if errors.Is(err, jsonrepair.ErrUnexpectedEnd) {
+//		// ask for more input
+//	}
+var (
+	ErrUnexpectedEnd       = errors.New("unexpected end of JSON")
+	ErrUnexpectedCharacter = errors.New("unexpected character")
+	ErrInvalidCharacter    = errors.New("invalid character")
+	ErrInvalidUnicode      = errors.New("invalid unicode escape")
+	ErrDepthExceeded       = errors.New("maximum depth exceeded")
+	ErrRepairDisabled      = errors.New("repair disabled")
+	ErrOutputFlushed       = errors.New("output already flushed")
+	ErrNotCanonical        = errors.New("cannot canonicalize")
+	ErrDuplicateKey        = errors.New("duplicate key")
+	ErrInputTooLarge       = errors.New("input too large")
+	ErrOutputTooLarge      = errors.New("output too large")
+	ErrStringTooLong       = errors.New("string too long")
+	ErrCanceled            = errors.New("repair canceled")
+	ErrNoJSON              = errors.New("no JSON found")
+	ErrNonFiniteNumber     = errors.New("non-finite number")
+)
+
+var codeErrors = map[Code]error{
+	CodeUnexpectedEnd:       ErrUnexpectedEnd,
+	CodeUnexpectedCharacter: ErrUnexpectedCharacter,
+	CodeInvalidCharacter:    ErrInvalidCharacter,
+	CodeInvalidUnicode:      ErrInvalidUnicode,
+	CodeDepthExceeded:       ErrDepthExceeded,
+	CodeRepairDisabled:      ErrRepairDisabled,
+	CodeOutputFlushed:       ErrOutputFlushed,
+	CodeNotCanonical:        ErrNotCanonical,
+	CodeDuplicateKey:        ErrDuplicateKey,
+	CodeInputTooLarge:       ErrInputTooLarge,
+	CodeOutputTooLarge:      ErrOutputTooLarge,
+	CodeStringTooLong:       ErrStringTooLong,
+	CodeCanceled:            ErrCanceled,
+	CodeNoJSON:              ErrNoJSON,
+	CodeNonFiniteNumber:     ErrNonFiniteNumber,
+}
+
+// String returns a short description of the code
+func (c Code) String() string {
+	if err, ok := codeErrors[c]; ok {
+		return err.Error()
+	}
+	return "unknown error"
+}
+
+// Is reports whether target is the sentinel error of the code of e, so
+// that errors.Is(err, ErrUnexpectedEnd) matches a *JSONRepairError
+func (e *JSONRepairError) Is(target error) bool {
+	return target != nil && codeErrors[e.Code] == target
+}
+
+// snippetContext is the maximum number of runes shown on either side of
+// the error position in JSONRepairError.Snippet
+const snippetContext = 40
+
+// identifierRegex matches keys that can be written as .key in a JSON path
+var identifierRegex = regexp.MustCompile(`^[A-Za-z_$][A-Za-z0-9_$]*$`)
+
+// newError creates a JSONRepairError at input offset pos, locating it in
+// the input and in the containers being parsed
+func (p *Parser) newError(code Code, message string, pos int) *JSONRepairError {
+	err := NewJSONRepairError(message, pos)
+	err.Code = code
+	err.Line, err.Column, err.Snippet = p.locate(pos)
+	if p.failPath != "" && p.failPos == pos {
+		err.Path = p.failPath
+	} else {
+		err.Path = p.path()
+	}
+	return err
+}
+
+// noteFailure remembers the container that failed to parse at the current
+// position, as the error for that position is only raised once the parser
+// has left the container
+func (p *Parser) noteFailure() {
+	p.failPos = p.offset + p.i
+	p.failPath = p.path()
+}
+
+// locate returns the line, column and snippet of input offset pos. When
+// streaming, the snippet only covers input that is still buffered.
+func (p *Parser) locate(pos int) (line, column int, snippet string) {
+	rel := min(max(pos-p.offset, 0), len(p.text))
+	before := p.text[:rel]
+
+	line = p.lines + strings.Count(before, "\n") + 1
+	truncated := false
+	if nl := strings.LastIndexByte(before, '\n'); nl != -1 {
+		before = before[nl+1:]
+		column = utf8.RuneCountInString(before) + 1
+	} else {
+		column = p.column + utf8.RuneCountInString(before) + 1
+		truncated = p.column > 0
+	}
+
+	after := p.text[rel:]
+	if nl := strings.IndexByte(after, '\n'); nl != -1 {
+		after = after[:nl]
+	}
+	after = strings.TrimSuffix(after, "\r")
+
+	prefix, suffix := "", ""
+	if n := utf8.RuneCountInString(before); n > snippetContext {
+		before = before[runeOffset(before, n-snippetContext):]
+		truncated = true
+	}
+	if truncated {
+		prefix = "..."
+	}
+	if utf8.RuneCountInString(after) > snippetContext {
+		after = after[:runeOffset(after, snippetContext)]
+		suffix = "..."
+	}
+
+	// Align the caret, keeping tabs so that it lines up in a terminal
+	caret := strings.Map(func(r rune) rune {
+		if r == '\t' {
+			return r
+		}
+		return ' '
+	}, prefix+before)
+
+	snippet = prefix + before + after + suffix + "\n" + caret + "^"
+	return line, column, snippet
+}
+
+// runeOffset returns the byte offset of the n-th rune of s
+func runeOffset(s string, n int) int {
+	offset := 0
+	for ; n > 0 && offset < len(s); n-- {
+		_, size := utf8.DecodeRuneInString(s[offset:])
+		offset += size
+	}
+	return offset
+}
+
+// path returns the JSON path of the innermost container being parsed
+func (p *Parser) path() string {
+	end := len(p.stack) - 1
+	for end >= 0 && p.stack[end].kind == frameFunctionCall {
+		end--
+	}
+
+	var b strings.Builder
+	b.WriteString("$")
+	for _, f := range p.stack[:max(end, 0)] {
+		switch f.kind {
+		case frameObject:
+			var key string
+			if err := json.Unmarshal([]byte(f.key), &key); err != nil {
+				key = f.key
+			}
+			writePathKey(&b, key)
+		case frameArray, frameNewlineDelimited:
+			writePathIndex(&b, f.index)
+		}
+	}
+	return b.String()
+}
+
+// writePathKey appends the member key of an object to a JSON path
+func writePathKey(b *strings.Builder, key string) {
+	if identifierRegex.MatchString(key) {
+		b.WriteString("." + key)
+	} else {
+		b.WriteString("[" + strconv.Quote(key) + "]")
+	}
+}
+
+// writePathIndex appends the index of an array item to a JSON path
+func writePathIndex(b *strings.Builder, index int) {
+	b.WriteString("[" + strconv.Itoa(index) + "]")
+}
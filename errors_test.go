@@ -0,0 +1,136 @@
+package jsonrepair
+
+import (
+	"errors"
+	"strings"
+	"testing"
+)
+
+func TestJSONRepairErrorLocation(t *testing.T) {
+	tests := []struct {
+		input   string
+		opts    Options
+		line    int
+		column  int
+		path    string
+		snippet string
+	}{
+		{"", Options{}, 1, 1, "$", "\n^"},
+		{`{"a":2}foo`, Options{}, 1, 8, "$", "{\"a\":2}foo\n       ^"},
+		{"{\n  \"a\": [1]\n}}x\n", Options{}, 3, 3, "$", "}}x\n  ^"},
+		{"\t[1]\t;", Options{}, 1, 6, "$", "\t[1]\t;\n\t   \t^"},
+		{`{"ä": "ö"} x`, Options{}, 1, 12, "$", "{\"ä\": \"ö\"} x\n           ^"},
+		{"[1]\r\n;", Options{}, 2, 1, "$", ";\n^"},
+		{
+			"{\"users\": [{\"name\": \"a\"},\n {\"name\": 'b'}]}",
+			Options{Disable: []RepairKind{RepairQuotes}},
+			2, 11, "$.users[1]", " {\"name\": 'b'}]}\n          ^",
+		},
+		{
+			"{\"id\":1}\n{\"id\":2}\n{\"x\": {\"y z\": [None]}}",
+			Options{Disable: []RepairKind{RepairPythonConstant}},
+			3, 16, `$[2].x["y z"]`, "{\"x\": {\"y z\": [None]}}\n               ^",
+		},
+		{
+			`[1, 2, 3, 4, 5, 6, 7, 8, 9, 10, 11, 12, 13, 14, 15] x [1, 2, 3, 4, 5, 6, 7, 8, 9, 10, 11, 12, 13, 14, 15]`,
+			Options{},
+			1, 53, "$", "... 5, 6, 7, 8, 9, 10, 11, 12, 13, 14, 15] x [1, 2, 3, 4, 5, 6, 7, 8, 9, 10, 11, 12...\n" +
+				"                                           ^",
+		},
+	}
+
+	for _, tt := range tests {
+		_, err := JSONRepairWithOptions(tt.input, tt.opts)
+		var repairErr *JSONRepairError
+		if !errors.As(err, &repairErr) {
+			t.Errorf("JSONRepairWithOptions(%q): expected *JSONRepairError, got %v", tt.input, err)
+			continue
+		}
+		if repairErr.Line != tt.line || repairErr.Column != tt.column || repairErr.Path != tt.path {
+			t.Errorf("JSONRepairWithOptions(%q) error at %d:%d in %s; want %d:%d in %s",
+				tt.input, repairErr.Line, repairErr.Column, repairErr.Path, tt.line, tt.column, tt.path)
+		}
+		if repairErr.Snippet != tt.snippet {
+			t.Errorf("JSONRepairWithOptions(%q) snippet =\n%s\nwant\n%s", tt.input, repairErr.Snippet, tt.snippet)
+		}
+	}
+}
+
+func TestRepairStreamErrorLocation(t *testing.T) {
+	defer func(chunk, buffer int) {
+		streamChunkSize, streamBufferSize = chunk, buffer
+	}(streamChunkSize, streamBufferSize)
+	streamChunkSize, streamBufferSize = 16, 32
+
+	input := "[\n" + strings.Repeat("  {\"ä\": 'ö', \"b\": [1, 2]},\n", 50) + "  {\"c\": {\"d\": None}}\n]"
+	opts := Options{Disable: []RepairKind{RepairPythonConstant}}
+	_, expectedErr := JSONRepairWithOptions(input, opts)
+	err := RepairStreamWithOptions(strings.NewReader(input), &strings.Builder{}, opts)
+
+	var expected, actual *JSONRepairError
+	if !errors.As(expectedErr, &expected) || !errors.As(err, &actual) {
+		t.Fatalf("RepairStreamWithOptions error = %v, want %v", err, expectedErr)
+	}
+	if expected.Line != 52 || expected.Column != 15 || expected.Path != "$[50].c" {
+		t.Errorf("JSONRepairWithOptions error at %d:%d in %s", expected.Line, expected.Column, expected.Path)
+	}
+	if actual.Position != expected.Position || actual.Line != expected.Line ||
+		actual.Column != expected.Column || actual.Path != expected.Path {
+		t.Errorf("RepairStreamWithOptions error at %d:%d in %s; want %d:%d in %s",
+			actual.Line, actual.Column, actual.Path, expected.Line, expected.Column, expected.Path)
+	}
+}
+
+func TestErrorCodes(t *testing.T) {
+	tests := []struct {
+		input  string
+		opts   Options
+		code   Code
+		target error
+	}{
+		{"", Options{}, CodeUnexpectedEnd, ErrUnexpectedEnd},
+		{`{"a":2}foo`, Options{}, CodeUnexpectedCharacter, ErrUnexpectedCharacter},
+		{"\"abc\x01\"", Options{}, CodeInvalidCharacter, ErrInvalidCharacter},
+		{`["\u12x4"]`, Options{}, CodeInvalidUnicode, ErrInvalidUnicode},
+		{`[1, 2,]`, Options{Disable: []RepairKind{RepairTrailingComma}}, CodeRepairDisabled, ErrRepairDisabled},
+	}
+
+	for _, tt := range tests {
+		_, err := JSONRepairWithOptions(tt.input, tt.opts)
+		var repairErr *JSONRepairError
+		if !errors.As(err, &repairErr) || repairErr.Code != tt.code {
+			t.Errorf("JSONRepairWithOptions(%q) error = %v, want code %v", tt.input, err, tt.code)
+			continue
+		}
+		for _, target := range codeErrors {
+			if errors.Is(err, target) != (target == tt.target) {
+				t.Errorf("errors.Is(%v, %v) = %v", err, target, !(target == tt.target))
+			}
+		}
+	}
+}
+
+func TestErrorCodeOutputFlushed(t *testing.T) {
+	defer func(chunk, buffer int) {
+		streamChunkSize, streamBufferSize = chunk, buffer
+	}(streamChunkSize, streamBufferSize)
+	streamChunkSize, streamBufferSize = 16, 32
+
+	input := "[" + strings.Repeat("1, ", 100) + "1]\n[2]"
+	err := RepairStream(strings.NewReader(input), &strings.Builder{})
+	if !errors.Is(err, ErrOutputFlushed) {
+		t.Errorf("RepairStream error = %v, want ErrOutputFlushed", err)
+	}
+}
+
+func TestCodeString(t *testing.T) {
+	if CodeUnexpectedEnd.String() != "unexpected end of JSON" {
+		t.Errorf("CodeUnexpectedEnd.String() = %q", CodeUnexpectedEnd.String())
+	}
+	if Code(0).String() != "unknown error" {
+		t.Errorf("Code(0).String() = %q", Code(0).String())
+	}
+	if errors.Is(NewJSONRepairError("custom", 0), ErrUnexpectedEnd) {
+		t.Error("an error without code must not match ErrUnexpectedEnd")
+	}
+}
@@ -0,0 +1,236 @@
+package jsonrepair
+
+import (
+	"slices"
+	"strings"
+)
+
+// Candidate is a JSON document found in surrounding text
+type Candidate struct {
+	Output     string   // Repaired document
+	Start      int      // Byte offset in the text where the document starts
+	End        int      // Byte offset in the text just after the document
+	Repairs    []Repair // Repairs applied to the document, ordered by input offset
+	Fenced     bool     // Whether the document is in a markdown code block
+	Confidence float64  // How likely the document is the one the text is about, from 0 to 1
+}
+
+// Extract finds the most plausible JSON object or array in text, like the
+// reply of a language model with a preamble and a trailer, and repairs it.
+// The document may be in a markdown code block or not. Text[Start:End] of
+// the result is the part of the text that was repaired.
+//
+// Example:
+//
+//	found, err := jsonrepair.Extract("Sure! Here is the result: {name: 'John'} Let me know if...")
+//	fmt.Println(found.Output) // {"name": "John"}
+//
+// When no object or array can be repaired, the error is a *JSONRepairError
+// with CodeNoJSON.
+func Extract(text string) (*Candidate, error) {
+	return ExtractWithOptions(text, Options{})
+}
+
+// ExtractWithOptions is like Extract, applying only the repairs allowed by
+// opts
+func ExtractWithOptions(text string, opts Options) (*Candidate, error) {
+	candidates, err := ExtractAllWithOptions(text, opts)
+	if err != nil {
+		return nil, err
+	}
+	if len(candidates) == 0 {
+		return nil, NewParserWithOptions(text, opts).newError(CodeNoJSON, "No JSON found", 0)
+	}
+	best := 0
+	for j, c := range candidates {
+		if c.Confidence > candidates[best].Confidence {
+			best = j
+		}
+	}
+	return &candidates[best], nil
+}
+
+// ExtractAll finds every JSON object or array in text that can be repaired,
+// like several documents in the reply of a language model or in a log file,
+// ordered by their position in the text. Each markdown code block and each
+// outermost object or array outside of them is a candidate. Objects and
+// arrays nested in a candidate are only candidates themselves when they
+// are more plausible than it, like in "Use {placeholders. {...}".
+//
+// Example:
+//
+//	candidates, err := jsonrepair.ExtractAll("First {a: 1}, then [1, 2,]")
+//	// candidates[0].Output: {"a": 1}
+//	// candidates[1].Output: [1, 2]
+func ExtractAll(text string) ([]Candidate, error) {
+	return ExtractAllWithOptions(text, Options{})
+}
+
+// ExtractAllWithOptions is like ExtractAll, applying only the repairs
+// allowed by opts
+func ExtractAllWithOptions(text string, opts Options) ([]Candidate, error) {
+	if err := NewParserWithOptions(text, opts).inputSizeError(); err != nil {
+		return nil, err
+	}
+	return outermost(findCandidates(text, opts)), nil
+}
+
+// outermost removes the candidates nested in a candidate that is at least
+// as plausible. The candidates are swept in the order of their start, the
+// outer ones first, so that the candidates enclosing one are those seen
+// before it that end after it. Their highest confidence is looked up in a
+// tree indexed by the end of the candidates.
+func outermost(candidates []Candidate) []Candidate {
+	slices.SortStableFunc(candidates, func(a, b Candidate) int {
+		if a.Start != b.Start {
+			return a.Start - b.Start
+		}
+		return b.End - a.End
+	})
+
+	// The rank of each end, counting from the last one
+	ends := make([]int, len(candidates))
+	for j, c := range candidates {
+		ends[j] = c.End
+	}
+	slices.Sort(ends)
+	ends = slices.Compact(ends)
+	rank := func(end int) int {
+		j, _ := slices.BinarySearch(ends, end)
+		return len(ends) - j
+	}
+
+	// tree is a Fenwick tree of the highest confidence of the candidates
+	// seen so far by the rank of their end, -1 where there are none
+	tree := make([]float64, len(ends)+1)
+	for j := range tree {
+		tree[j] = -1
+	}
+	var kept []Candidate
+	for _, c := range candidates {
+		enclosing := -1.0
+		for j := rank(c.End); j > 0; j -= j & -j {
+			enclosing = max(enclosing, tree[j])
+		}
+		if enclosing < c.Confidence {
+			kept = append(kept, c)
+		}
+		for j := rank(c.End); j < len(tree); j += j & -j {
+			tree[j] = max(tree[j], c.Confidence)
+		}
+	}
+	return kept
+}
+
+// findCandidates repairs the objects and arrays found in text, inside and
+// outside of markdown code blocks
+func findCandidates(text string, opts Options) []Candidate {
+	var found []Candidate
+	i := 0
+	for i < len(text) {
+		open := strings.Index(text[i:], "```")
+		if open == -1 {
+			break
+		}
+		open += i
+		found = appendCandidates(found, text, i, open, opts, false)
+
+		// The code block lasts until its closing fence or the end of the text
+		start, end, next := open+3, len(text), len(text)
+		if n := strings.Index(text[start:], "```"); n != -1 {
+			end, next = start+n, start+n+3
+		}
+		found = appendCandidates(found, text, start, end, opts, true)
+		i = next
+	}
+	return appendCandidates(found, text, i, len(text), opts, false)
+}
+
+// nestedSearchConfidence is the confidence below which the search for
+// candidates continues inside a candidate, as a bracket in the prose may
+// swallow the document following it, like in "Use {placeholders. {...}"
+const nestedSearchConfidence = 0.5
+
+// nestedSearchBudget is how many times the text is parsed at most while
+// searching inside candidates, which keeps the search linear in the length
+// of the text when it is full of brackets
+const nestedSearchBudget = 8
+
+// appendCandidates appends the candidates starting with a bracket between
+// start and limit to found. A candidate is repaired from its bracket up to
+// limit at most, and the search continues after its end unless it is
+// unlikely to be a document and the text parsed so far is within the
+// budget.
+func appendCandidates(found []Candidate, text string, start, limit int, opts Options, fenced bool) []Candidate {
+	// A code block holds a single document, from its first bracket to its
+	// last non-whitespace character
+	block := 0
+	if fenced {
+		first := strings.IndexAny(text[start:limit], "{[")
+		if first == -1 {
+			return found
+		}
+		block = len(strings.TrimRight(text[start+first:limit], " \t\r\n"))
+	}
+
+	budget := nestedSearchBudget * (limit - start)
+	for i := start; i < limit; i++ {
+		if text[i] != '{' && text[i] != '[' {
+			continue
+		}
+		c, parsed, ok := repairCandidate(text, i, limit, opts)
+		budget -= parsed
+		if ok {
+			c.Fenced = fenced
+			c.Confidence = c.score(block)
+			found = append(found, c)
+			if c.Confidence >= nestedSearchConfidence || budget < 0 {
+				i = c.End - 1
+			}
+		} else if budget < 0 {
+			i += max(parsed, 1) - 1
+		}
+	}
+	return found
+}
+
+// repairCandidate repairs the value starting at start, leaving the text
+// after it. It also returns the length of the text parsed, even when the
+// repair fails.
+func repairCandidate(text string, start, limit int, opts Options) (Candidate, int, bool) {
+	p := NewParserWithOptions(text[start:limit], opts)
+	p.offset = start
+	p.extracting = true
+	p.enableReport()
+	if err := p.parse(); err != nil {
+		return Candidate{}, p.i, false
+	}
+	if err := p.finish(); err != nil {
+		return Candidate{}, p.i, false
+	}
+
+	// The parser skips the whitespace after the value
+	end := start + len(strings.TrimRight(p.text[:p.i], " \t\r\n"))
+	return Candidate{Output: p.output.String(), Start: start, End: end, Repairs: p.report()}, p.i, true
+}
+
+// score estimates how likely the candidate is the document the text is
+// about. Small values are often part of the prose, like {name}, and values
+// with much of their text repaired are likely not JSON at all. A
+// candidate in a code block of the given length is likely the document
+// when it spans the whole block.
+func (c *Candidate) score(block int) float64 {
+	size := float64(c.End - c.Start)
+	confidence := size / (size + 8)
+	repaired := 0
+	for _, r := range c.Repairs {
+		repaired += max(len(r.Original), 1)
+	}
+	confidence /= 1 + 4*float64(repaired)/size
+	if c.Fenced {
+		confidence *= size / float64(max(block, c.End-c.Start))
+	} else {
+		confidence *= 0.75
+	}
+	return confidence
+}
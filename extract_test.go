@@ -0,0 +1,220 @@
+package jsonrepair
+
+import (
+	"errors"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestExtract(t *testing.T) {
+	tests := []struct {
+		name     string
+		input    string
+		expected string
+		span     string
+	}{
+		{
+			name:     "preamble and trailer",
+			input:    `Sure! Here is the result: {"a": 1, "b": [1, 2]} Let me know if you need anything else.`,
+			expected: `{"a": 1, "b": [1, 2]}`,
+			span:     `{"a": 1, "b": [1, 2]}`,
+		},
+		{
+			name:     "fenced",
+			input:    "Here you go:\n```json\n{name: 'John', age: 30,}\n```\nAnything else?",
+			expected: `{"name": "John", "age": 30}`,
+			span:     `{name: 'John', age: 30,}`,
+		},
+		{
+			name:     "unclosed fence",
+			input:    "Here you go:\n```json\n[1, 2, 3",
+			expected: `[1, 2, 3]`,
+			span:     `[1, 2, 3`,
+		},
+		{
+			name:     "array",
+			input:    "The answer is [1, 2, 3].",
+			expected: `[1, 2, 3]`,
+			span:     `[1, 2, 3]`,
+		},
+		{
+			name:     "truncated",
+			input:    `Truncated: {"a": [1, 2, {"b": "c`,
+			expected: `{"a": [1, 2, {"b": "c"}]}`,
+			span:     `{"a": [1, 2, {"b": "c`,
+		},
+		{
+			name:     "braces in the prose",
+			input:    `Use {name} as a placeholder. The data: {"id": 12, "tags": ["x", "y"]}`,
+			expected: `{"id": 12, "tags": ["x", "y"]}`,
+			span:     `{"id": 12, "tags": ["x", "y"]}`,
+		},
+		{
+			name:     "unclosed brace in the prose",
+			input:    `Use {placeholders like this. Result: {"a": 1, "b": 2}`,
+			expected: `{"a": 1, "b": 2}`,
+			span:     `{"a": 1, "b": 2}`,
+		},
+		{
+			name:     "fenced before unfenced",
+			input:    "Data:\n```\n[1, 2]\n```\nand more {\"x\": 1} text",
+			expected: `[1, 2]`,
+			span:     `[1, 2]`,
+		},
+		{
+			name:     "larger document",
+			input:    `First {"a": 1}, then {"a": 1, "b": {"c": [true, false]}}.`,
+			expected: `{"a": 1, "b": {"c": [true, false]}}`,
+			span:     `{"a": 1, "b": {"c": [true, false]}}`,
+		},
+		{
+			name:     "no prose",
+			input:    `{"a": 1}`,
+			expected: `{"a": 1}`,
+			span:     `{"a": 1}`,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			found, err := Extract(tt.input)
+			if err != nil {
+				t.Fatalf("Extract(%q) error: %v", tt.input, err)
+			}
+			if found.Output != tt.expected {
+				t.Errorf("Extract(%q).Output = %q, want %q", tt.input, found.Output, tt.expected)
+			}
+			if span := tt.input[found.Start:found.End]; span != tt.span {
+				t.Errorf("Extract(%q) span = %q, want %q", tt.input, span, tt.span)
+			}
+		})
+	}
+}
+
+func TestExtractNoJSON(t *testing.T) {
+	for _, input := range []string{"", "no JSON here", "a ```fenced``` word"} {
+		_, err := Extract(input)
+		var repairErr *JSONRepairError
+		if !errors.As(err, &repairErr) || repairErr.Code != CodeNoJSON || !errors.Is(err, ErrNoJSON) {
+			t.Errorf("Extract(%q) error = %v, want ErrNoJSON", input, err)
+		}
+	}
+}
+
+func TestExtractWithOptions(t *testing.T) {
+	input := "Result:\n```json\n{a: [1, 2,],}\n```"
+	found, err := ExtractWithOptions(input, Options{Format: FormatCompact})
+	if err != nil || found.Output != `{"a":[1,2]}` {
+		t.Errorf("ExtractWithOptions(%q) = %+v, %v", input, found, err)
+	}
+
+	// A candidate needing a disabled repair is skipped
+	input = `Either {a: 1} or {"b": 2}`
+	found, err = ExtractWithOptions(input, Options{Disable: []RepairKind{RepairUnquotedString}})
+	if err != nil || found.Output != `{"b": 2}` {
+		t.Errorf("ExtractWithOptions(%q) = %+v, %v", input, found, err)
+	}
+
+	_, err = ExtractWithOptions(input, Options{MaxInputBytes: 10})
+	if !errors.Is(err, ErrInputTooLarge) {
+		t.Errorf("ExtractWithOptions(%q) with MaxInputBytes 10 returned %v", input, err)
+	}
+}
+
+func TestExtractAll(t *testing.T) {
+	input := "First {a: 1}, then:\n```json\n[1, 2,]\n```\nand {\"b\": {\"c\": [true]}} in it."
+	candidates, err := ExtractAll(input)
+	if err != nil {
+		t.Fatalf("ExtractAll(%q) error: %v", input, err)
+	}
+	expected := []struct {
+		output  string
+		span    string
+		repairs []RepairKind
+		fenced  bool
+	}{
+		{`{"a": 1}`, `{a: 1}`, []RepairKind{RepairUnquotedString}, false},
+		{`[1, 2]`, `[1, 2,]`, []RepairKind{RepairTrailingComma}, true},
+		{`{"b": {"c": [true]}}`, `{"b": {"c": [true]}}`, nil, false},
+	}
+	if len(candidates) != len(expected) {
+		t.Fatalf("ExtractAll(%q) returned %d candidates, want %d: %+v", input, len(candidates), len(expected), candidates)
+	}
+	for j, c := range candidates {
+		want := expected[j]
+		if c.Output != want.output || input[c.Start:c.End] != want.span || c.Fenced != want.fenced {
+			t.Errorf("Candidate %d = %+v, want output %q, span %q, fenced %v", j, c, want.output, want.span, want.fenced)
+		}
+		if len(c.Repairs) != len(want.repairs) {
+			t.Errorf("Candidate %d repairs = %+v, want %v", j, c.Repairs, want.repairs)
+			continue
+		}
+		for k, r := range c.Repairs {
+			if r.Kind != want.repairs[k] {
+				t.Errorf("Candidate %d repair %d kind = %v, want %v", j, k, r.Kind, want.repairs[k])
+			}
+		}
+		if c.Confidence <= 0 || c.Confidence > 1 {
+			t.Errorf("Candidate %d confidence = %v, want between 0 and 1", j, c.Confidence)
+		}
+	}
+
+	// The largest document without repairs is the most plausible
+	best, _ := Extract(input)
+	if best.Output != candidates[2].Output {
+		t.Errorf("Extract(%q) = %+v, candidates %+v", input, best, candidates)
+	}
+}
+
+func TestExtractAllNested(t *testing.T) {
+	// A bracket in the prose swallows the document after it, which is a
+	// candidate of its own
+	input := `Use {placeholders like this. Result: {"a": 1, "b": 2}`
+	candidates, err := ExtractAll(input)
+	if err != nil || len(candidates) != 2 || candidates[1].Output != `{"a": 1, "b": 2}` {
+		t.Fatalf("ExtractAll(%q) = %+v, %v", input, candidates, err)
+	}
+
+	// Values nested in a more plausible document are not candidates
+	input = "```\n{\"a\": [1, 2], \"b\": {\"c\": 3}}\n```"
+	candidates, err = ExtractAll(input)
+	if err != nil || len(candidates) != 1 || candidates[0].Output != `{"a": [1, 2], "b": {"c": 3}}` {
+		t.Errorf("ExtractAll(%q) = %+v, %v", input, candidates, err)
+	}
+
+	candidates, err = ExtractAll("no JSON here")
+	if err != nil || len(candidates) != 0 {
+		t.Errorf("ExtractAll without JSON = %+v, %v", candidates, err)
+	}
+}
+
+func TestExtractAllManyBrackets(t *testing.T) {
+	// Each bracket starts a candidate that swallows the rest of the text.
+	// Searching inside all of them would take quadratic time.
+	for _, input := range []string{
+		strings.Repeat("Use {x. ", 5000),
+		strings.Repeat("[", 20000),
+	} {
+		start := time.Now()
+		if _, err := ExtractAll(input); err != nil {
+			t.Errorf("ExtractAll of %d bytes error: %v", len(input), err)
+		}
+		if elapsed := time.Since(start); elapsed > 5*time.Second {
+			t.Errorf("ExtractAll of %d bytes took %v", len(input), elapsed)
+		}
+	}
+}
+
+func TestExtractAllManyCandidates(t *testing.T) {
+	// Comparing every candidate with every other one would take quadratic
+	// time
+	input := strings.Repeat("log line {\"a\": 1}\n", 40000)
+	start := time.Now()
+	candidates, err := ExtractAll(input)
+	if err != nil || len(candidates) != 40000 {
+		t.Fatalf("ExtractAll of %d bytes = %d candidates, %v", len(input), len(candidates), err)
+	}
+	if elapsed := time.Since(start); elapsed > 5*time.Second {
+		t.Errorf("ExtractAll of %d bytes took %v", len(input), elapsed)
+	}
+}
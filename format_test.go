@@ -0,0 +1,132 @@
+package jsonrepair
+
+import (
+	"bytes"
+	"encoding/json"
+	"strings"
+	"testing"
+	"testing/iotest"
+)
+
+func TestFormat(t *testing.T) {
+	tests := []struct {
+		name     string
+		input    string
+		opts     Options
+		expected string
+	}{
+		{"compact", "{\n  \"a\": 1,\n  \"b\": [1, 2, 3]\n}", Options{Format: FormatCompact}, `{"a":1,"b":[1,2,3]}`},
+		{"compact repairs", "{a: 1 b: [1, 2,", Options{Format: FormatCompact}, `{"a":1,"b":[1,2]}`},
+		{"compact comments", "/* c */ {\"a\": 1, // x\n \"b\": 2}", Options{Format: FormatCompact}, `{"a":1,"b":2}`},
+		{"compact newline delimited", "{\"a\": 1}\n{\"b\": 2}\n", Options{Format: FormatCompact}, `[{"a":1},{"b":2}]`},
+		{"compact keeps string whitespace", `{"a b": " c "}`, Options{Format: FormatCompact}, `{"a b":" c "}`},
+		{"indent", `{"a":1,"b":[1,{"c":null}],"d":{},"e":[ ]}`, Options{Format: FormatIndent},
+			"{\n  \"a\": 1,\n  \"b\": [\n    1,\n    {\n      \"c\": null\n    }\n  ],\n  \"d\": {},\n  \"e\": []\n}"},
+		{"indent missing brackets", `{"a": [1, 2`, Options{Format: FormatIndent},
+			"{\n  \"a\": [\n    1,\n    2\n  ]\n}"},
+		{"indent trailing comma", `[1, 2, ]`, Options{Format: FormatIndent}, "[\n  1,\n  2\n]"},
+		{"indent missing colon and value", `{"a" 1, "b":}`, Options{Format: FormatIndent}, "{\n  \"a\": 1,\n  \"b\": null\n}"},
+		{"indent newline delimited", "{\"a\": [1]}\n{\"b\": 2}", Options{Format: FormatIndent},
+			"[\n  {\n    \"a\": [\n      1\n    ]\n  },\n  {\n    \"b\": 2\n  }\n]"},
+		{"indent markdown fence", "```json\n{\"a\": 1}\n```", Options{Format: FormatIndent}, "{\n  \"a\": 1\n}"},
+		{"indent function call", `callback({"a": 1});`, Options{Format: FormatIndent}, "{\n  \"a\": 1\n}"},
+		{"indent with tabs", `{"a": [1]}`, Options{Format: FormatIndent, Indent: "\t"}, "{\n\t\"a\": [\n\t\t1\n\t]\n}"},
+		{"indent scalar", `  'abc'  `, Options{Format: FormatIndent}, `"abc"`},
+		{"compact missing end quote", `{"a": "b  `, Options{Format: FormatCompact}, `{"a":"b"}`},
+		{"indent missing end quote", `{"a": "b  `, Options{Format: FormatIndent}, "{\n  \"a\": \"b\"\n}"},
+		{"compact missing end quote in array", `["abc  ]`, Options{Format: FormatCompact}, `["abc"]`},
+		{"indent missing end quote in array", `["abc  ]`, Options{Format: FormatIndent}, "[\n  \"abc\"\n]"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			result, err := JSONRepairWithOptions(tt.input, tt.opts)
+			if err != nil {
+				t.Fatalf("JSONRepairWithOptions(%q) error: %v", tt.input, err)
+			}
+			if result != tt.expected {
+				t.Errorf("JSONRepairWithOptions(%q) = %q, want %q", tt.input, result, tt.expected)
+			}
+		})
+	}
+}
+
+// formatted returns the output expected for input with the given format,
+// which is the output of JSONRepair formatted by encoding/json
+func formatted(t *testing.T, input string, format Format) (string, bool) {
+	t.Helper()
+	result, err := JSONRepair(input)
+	if err != nil {
+		return "", false
+	}
+	var buf bytes.Buffer
+	if format == FormatCompact {
+		err = json.Compact(&buf, []byte(result))
+	} else {
+		err = json.Indent(&buf, []byte(result), "", "  ")
+	}
+	if err != nil {
+		t.Fatalf("JSONRepair(%q) returned invalid JSON %q: %v", input, result, err)
+	}
+	return strings.TrimSpace(buf.String()), true
+}
+
+func TestFormatMatchesEncodingJSON(t *testing.T) {
+	for _, format := range []Format{FormatCompact, FormatIndent} {
+		for _, input := range streamTestInputs {
+			expected, ok := formatted(t, input, format)
+			if !ok {
+				continue
+			}
+			opts := Options{Format: format}
+
+			result, err := JSONRepairWithOptions(input, opts)
+			if err != nil || result != expected {
+				t.Errorf("JSONRepairWithOptions(%q, %v) = %q, %v, want %q", input, format, result, err, expected)
+			}
+
+			var out bytes.Buffer
+			err = RepairStreamWithOptions(iotest.OneByteReader(strings.NewReader(input)), &out, opts)
+			if err != nil || out.String() != expected {
+				t.Errorf("RepairStreamWithOptions(%q, %v) = %q, %v, want %q", input, format, out.String(), err, expected)
+			}
+		}
+	}
+}
+
+func TestFormatRepairer(t *testing.T) {
+	for _, format := range []Format{FormatCompact, FormatIndent} {
+		opts := Options{Format: format}
+		for _, input := range streamTestInputs {
+			r := NewRepairerWithOptions(opts)
+			for i := 0; i < len(input); i++ {
+				r.WriteString(input[i : i+1])
+				prefix := input[:i+1]
+
+				expected, expectedErr := JSONRepairWithOptions(prefix, opts)
+				result, err := r.Snapshot()
+				if (err == nil) != (expectedErr == nil) || result != expected {
+					t.Fatalf("Snapshot after %q with %v = %q, %v, want %q, %v", prefix, format, result, err, expected, expectedErr)
+				}
+			}
+		}
+	}
+}
+
+func TestFormatReportOffsets(t *testing.T) {
+	for _, format := range []Format{FormatCompact, FormatIndent} {
+		for _, input := range streamTestInputs {
+			result, err := JSONRepairWithReport(input, Options{Format: format})
+			if err != nil {
+				continue
+			}
+			for _, r := range result.Repairs {
+				end := r.OutputOffset + len(r.Replacement)
+				if end > len(result.Output) || result.Output[r.OutputOffset:end] != r.Replacement {
+					t.Errorf("JSONRepairWithReport(%q, %v): %v repair %q not found at output offset %d of %q",
+						input, format, r.Kind, r.Replacement, r.OutputOffset, result.Output)
+				}
+			}
+		}
+	}
+}
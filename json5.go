@@ -0,0 +1,203 @@
+package jsonrepair
+
+import (
+	"math/big"
+	"strconv"
+	"strings"
+	"unicode/utf8"
+)
+
+// parseJSON5Number parses a JSON5 number that is no JSON number, like
+// 0x1F, .5, 5., +1, Infinity or NaN. Other input, including numbers that
+// are valid JSON, is left to parseNumber.
+func (p *Parser) parseJSON5Number() bool {
+	if p.dialect != DialectJSON5 || !p.has(p.i) {
+		return false
+	}
+	start := p.i
+	j := p.i
+	negative := false
+	if p.text[j] == '+' || p.text[j] == '-' {
+		negative = p.text[j] == '-'
+		j++
+	}
+
+	value := ""
+	switch {
+	case p.hasPrefix(j, "Infinity"):
+		j += len("Infinity")
+		value = "Infinity"
+		if negative {
+			value = "-Infinity"
+		}
+	case p.hasPrefix(j, "NaN"):
+		j += len("NaN")
+		value = "NaN"
+	case p.has(j+2) && p.text[j] == '0' && (p.text[j+1] == 'x' || p.text[j+1] == 'X') && isHex(rune(p.text[j+2])):
+		j += 2
+		digits := j
+		for p.has(j) && isHex(rune(p.text[j])) {
+			j++
+		}
+		n, _ := new(big.Int).SetString(p.text[digits:j], 16)
+		if negative {
+			n.Neg(n)
+		}
+		value = n.String()
+	default:
+		var ok bool
+		if value, j, ok = p.json5Decimal(j); !ok {
+			return false
+		}
+		if negative {
+			value = "-" + value
+		}
+		if value == p.text[start:j] {
+			// Valid JSON
+			return false
+		}
+	}
+
+	p.i = j
+	if !p.atEndOfNumber() {
+		p.i = start
+		return false
+	}
+	switch value {
+	case "NaN", "Infinity", "-Infinity":
+		value = p.nonFiniteValue(value, start)
+	}
+	p.repair(RepairJSON5Number, start, p.text[start:j], value)
+	p.output.WriteString(value)
+	return true
+}
+
+// json5Decimal parses the digits of a decimal number from position j,
+// which may start or end with a decimal point, and returns them as a JSON
+// number with the position after them. It returns false for numbers that
+// parseNumber repairs, like a truncated exponent or leading zeros.
+func (p *Parser) json5Decimal(j int) (string, int, bool) {
+	start := j
+	for p.has(j) && isDigit(rune(p.text[j])) {
+		j++
+	}
+	integer := p.text[start:j]
+	if len(integer) > 1 && integer[0] == '0' {
+		return "", 0, false
+	}
+	if integer == "" {
+		integer = "0"
+	}
+
+	fraction := ""
+	if p.has(j) && p.text[j] == '.' {
+		j++
+		digits := j
+		for p.has(j) && isDigit(rune(p.text[j])) {
+			j++
+		}
+		fraction = "." + p.text[digits:j]
+		if j == digits {
+			fraction += "0"
+		}
+	}
+	if j == start || j == start+1 && fraction != "" {
+		// No digits at all
+		return "", 0, false
+	}
+
+	exponent := ""
+	if p.has(j) && (p.text[j] == 'e' || p.text[j] == 'E') {
+		k := j + 1
+		if p.has(k) && (p.text[k] == '+' || p.text[k] == '-') {
+			k++
+		}
+		if !p.has(k) || !isDigit(rune(p.text[k])) {
+			return "", 0, false
+		}
+		for p.has(k) && isDigit(rune(p.text[k])) {
+			k++
+		}
+		exponent = p.text[j:k]
+		j = k
+	}
+	return integer + fraction + exponent, j, true
+}
+
+// hasPrefix reports whether the input at position j starts with prefix
+func (p *Parser) hasPrefix(j int, prefix string) bool {
+	return p.has(j+len(prefix)-1) && p.text[j:j+len(prefix)] == prefix
+}
+
+// parseJSON5Escape converts the JSON5 escape sequence at the current
+// position that JSON does not have, like \x41, \0, \v or a backslash
+// continuing the string on the next line. A backslash before any other
+// character that is no digit stands for that character, like \' does. It
+// returns false when it is no JSON5 escape either.
+func (p *Parser) parseJSON5Escape() bool {
+	if p.dialect != DialectJSON5 || !p.has(p.i+1) {
+		return false
+	}
+	start := p.i
+	next, size := utf8.DecodeRuneInString(p.text[p.i+1:])
+	escaped := ""
+	switch {
+	case next == '\r':
+		p.i += 2
+		if p.has(p.i) && p.text[p.i] == '\n' {
+			p.i++
+		}
+	case next == '\n' || next == '\u2028' || next == '\u2029':
+		p.i += 1 + size
+	case next == 'x':
+		if !p.has(p.i+3) || !isHexString(p.text[p.i+2:p.i+4]) {
+			return false
+		}
+		code, _ := strconv.ParseUint(p.text[p.i+2:p.i+4], 16, 8)
+		escaped = escapeRune(rune(code))
+		p.i += 4
+	case next == '0' && (!p.has(p.i+2) || !isDigit(rune(p.text[p.i+2]))):
+		escaped = escapeRune(0)
+		p.i += 2
+	case next == 'v':
+		escaped = escapeRune('\v')
+		p.i += 2
+	case isDigit(next) || next == 'u' || next == utf8.RuneError:
+		return false
+	default:
+		escaped = escapeRune(next)
+		p.i += 1 + size
+	}
+	p.repair(RepairJSON5String, start, p.text[start:p.i], escaped)
+	p.output.WriteString(escaped)
+	return true
+}
+
+// unescapeIdentifier decodes the \uXXXX escapes of a JSON5 identifier
+func unescapeIdentifier(name string) string {
+	if !strings.Contains(name, `\u`) {
+		return name
+	}
+	var b strings.Builder
+	for i := 0; i < len(name); i++ {
+		if name[i] == '\\' && i+6 <= len(name) && name[i+1] == 'u' && isHexString(name[i+2:i+6]) {
+			code, _ := strconv.ParseUint(name[i+2:i+6], 16, 32)
+			b.WriteRune(rune(code))
+			i += 5
+		} else {
+			b.WriteByte(name[i])
+		}
+	}
+	return b.String()
+}
+
+// isJSON5Whitespace checks if the character is whitespace in JSON5 but
+// neither in JSON nor special whitespace, like a byte order mark or a
+// vertical tab
+func isJSON5Whitespace(text string, index int) bool {
+	if index >= len(text) {
+		return false
+	}
+	r, _ := utf8.DecodeRuneInString(text[index:])
+	return r == '\v' || r == '\f' || r == '\u1680' || r == '\u2028' || r == '\u2029' || r == '\ufeff'
+}
@@ -0,0 +1,179 @@
+package jsonrepair
+
+import (
+	"bytes"
+	"errors"
+	"reflect"
+	"strings"
+	"testing"
+)
+
+var json5TestInputs = []string{
+	`{hex: 0x1F, neg: -0xff, half: .5, whole: 5., sign: +1, exp: 5.e2}`,
+	`[Infinity, -Infinity, +Infinity, NaN, 1.5, -0]`,
+	"{text: 'line \\\ncontinued \\v\\0\\x41 it\\'s'}",
+	`{café: 1, \u0041bc: 2, $id: 3, _x: 4,}`,
+	"\ufeff// comment\n{a: [1, 2,], 'b': \"c\"}",
+}
+
+func TestJSON5Dialect(t *testing.T) {
+	tests := []struct {
+		input    string
+		expected string
+	}{
+		{`0x1F`, `31`},
+		{`-0X1f`, `-31`},
+		{`+0xFF`, `255`},
+		{`0xFFFFFFFFFFFFFFFFFFFF`, `1208925819614629174706175`},
+		{`.5`, `0.5`},
+		{`-.5e3`, `-0.5e3`},
+		{`5.`, `5.0`},
+		{`5.e2`, `5.0e2`},
+		{`+1`, `1`},
+		{`+1.5E+2`, `1.5E+2`},
+		{`[Infinity, -Infinity, +Infinity, NaN, -NaN]`, `[null, null, null, null, null]`},
+		{`[1, -2.5e3, 0, -0]`, `[1, -2.5e3, 0, -0]`},
+		{`007`, `"007"`},
+		{`0x`, `"0x"`},
+		{"'line \\\ncontinued'", `"line continued"`},
+		{"'line \\\r\ncontinued'", `"line continued"`},
+		{"'line \\\u2028continued'", `"line continued"`},
+		{`'\v\0\x41'`, `"\u000b\u0000A"`},
+		{`'\x00\x7f'`, `"\u0000` + "\x7f" + `"`},
+		{`'it\'s \a\c'`, `"it's ac"`},
+		{`'\01'`, `"01"`},
+		{`"\u2605 \n"`, `"\u2605 \n"`},
+		{`{café: 1}`, `{"café": 1}`},
+		{`{\u0041bc: 1, a\u0062: 2}`, `{"Abc": 1, "ab": 2}`},
+		{`{$id: 1, _x: 2}`, `{"$id": 1, "_x": 2}`},
+		{"\ufeff{a: 1}", `{"a": 1}`},
+		{"{a:\v1,\f\u2028b: 2}", `{"a": 1,  "b": 2}`},
+		{"{\n  // comment\n  a: 'x',\n  b: [1, 2,],\n}", "{\n  \n  \"a\": \"x\",\n  \"b\": [1, 2]\n}"},
+	}
+	for _, tt := range tests {
+		result, err := JSONRepairWithOptions(tt.input, Options{Dialect: DialectJSON5})
+		if err != nil || result != tt.expected {
+			t.Errorf("JSONRepairWithOptions(%q, DialectJSON5) = %q, %v, want %q", tt.input, result, err, tt.expected)
+		}
+	}
+}
+
+func TestJSON5DialectOnlyWhenSelected(t *testing.T) {
+	for _, input := range []string{`0x1F`, `.5`, `[NaN]`, `'\x41'`, `{\u0041: 1}`} {
+		expected, _ := JSONRepairWithOptions(input, Options{Dialect: DialectJSON5})
+		if result, _ := JSONRepair(input); result == expected {
+			t.Errorf("JSONRepair(%q) = %q, repaired like DialectJSON5", input, result)
+		}
+	}
+}
+
+func TestNonFinite(t *testing.T) {
+	tests := []struct {
+		dialect   Dialect
+		nonFinite NonFinite
+		input     string
+		expected  string
+	}{
+		{DialectJSON5, NonFiniteNull, `[NaN, Infinity, -Infinity]`, `[null, null, null]`},
+		{DialectJSON5, NonFiniteString, `[NaN, Infinity, -Infinity, +Infinity]`, `["NaN", "Infinity", "-Infinity", "Infinity"]`},
+		{DialectPython, NonFiniteString, `[nan, inf, -inf]`, `["NaN", "Infinity", "-Infinity"]`},
+		{DialectPython, NonFiniteString, `[float('nan'), float('-inf'), Decimal('Infinity'), Decimal('sNaN')]`, `["NaN", "-Infinity", "Infinity", "NaN"]`},
+	}
+	for _, tt := range tests {
+		result, err := JSONRepairWithOptions(tt.input, Options{Dialect: tt.dialect, NonFinite: tt.nonFinite})
+		if err != nil || result != tt.expected {
+			t.Errorf("JSONRepairWithOptions(%q, %v) = %q, %v, want %q", tt.input, tt.nonFinite, result, err, tt.expected)
+		}
+	}
+
+	errorTests := []struct {
+		dialect  Dialect
+		input    string
+		position int
+	}{
+		{DialectJSON5, `[1, -Infinity]`, 4},
+		{DialectJSON5, `{a: NaN}`, 4},
+		{DialectPython, `[1, nan]`, 4},
+		{DialectPython, `[float('inf')]`, 1},
+	}
+	for _, tt := range errorTests {
+		_, err := JSONRepairWithOptions(tt.input, Options{Dialect: tt.dialect, NonFinite: NonFiniteError})
+		var repairErr *JSONRepairError
+		if !errors.As(err, &repairErr) || !errors.Is(err, ErrNonFiniteNumber) || repairErr.Position != tt.position {
+			t.Errorf("JSONRepairWithOptions(%q, NonFiniteError) error = %v, want ErrNonFiniteNumber at position %d", tt.input, err, tt.position)
+		}
+	}
+
+	// Valid JSON has no non-finite numbers
+	if result, err := JSONRepairWithOptions(`[1.5]`, Options{Dialect: DialectJSON5, NonFinite: NonFiniteError}); err != nil || result != `[1.5]` {
+		t.Errorf("JSONRepairWithOptions([1.5], NonFiniteError) = %q, %v", result, err)
+	}
+}
+
+func TestJSON5DialectReport(t *testing.T) {
+	input := "{a: .5, 'b': '\\x41\\\nc', c: NaN}"
+	result, err := JSONRepairWithReport(input, Options{Dialect: DialectJSON5})
+	if err != nil {
+		t.Fatalf("JSONRepairWithReport(%q) error: %v", input, err)
+	}
+	expected := []Repair{
+		{RepairUnquotedString, 1, 1, "a", `"a"`},
+		{RepairJSON5Number, 4, 6, ".5", "0.5"},
+		{RepairQuotes, 8, 11, "'", `"`},
+		{RepairQuotes, 10, 13, "'", `"`},
+		{RepairQuotes, 13, 16, "'", `"`},
+		{RepairJSON5String, 14, 17, `\x41`, "A"},
+		{RepairJSON5String, 18, 18, "\\\n", ""},
+		{RepairQuotes, 21, 19, "'", `"`},
+		{RepairUnquotedString, 24, 22, "c", `"c"`},
+		{RepairJSON5Number, 27, 27, "NaN", "null"},
+	}
+	if !reflect.DeepEqual(result.Repairs, expected) {
+		t.Errorf("JSONRepairWithReport(%q).Repairs =\n%+v\nwant\n%+v", input, result.Repairs, expected)
+	}
+
+	for _, input := range json5TestInputs {
+		result, err := JSONRepairWithReport(input, Options{Dialect: DialectJSON5})
+		if err != nil {
+			t.Fatalf("JSONRepairWithReport(%q) error: %v", input, err)
+		}
+		for _, r := range result.Repairs {
+			if input[r.InputOffset:r.InputOffset+len(r.Original)] != r.Original ||
+				result.Output[r.OutputOffset:r.OutputOffset+len(r.Replacement)] != r.Replacement {
+				t.Errorf("JSONRepairWithReport(%q): %+v does not match the input or the output %q", input, r, result.Output)
+			}
+		}
+	}
+
+	for _, kind := range []RepairKind{RepairJSON5String, RepairJSON5Number} {
+		if _, err := JSONRepairWithOptions(input, Options{Dialect: DialectJSON5, Disable: []RepairKind{kind}}); err == nil {
+			t.Errorf("JSONRepairWithOptions(%q) with %v disabled succeeded", input, kind)
+		}
+	}
+}
+
+func TestJSON5DialectStream(t *testing.T) {
+	opts := Options{Dialect: DialectJSON5}
+	for _, input := range json5TestInputs {
+		expected, expectedErr := JSONRepairWithOptions(input, opts)
+		if expectedErr != nil {
+			t.Fatalf("JSONRepairWithOptions(%q) error: %v", input, expectedErr)
+		}
+
+		var out bytes.Buffer
+		if err := RepairStreamWithOptions(strings.NewReader(input), &out, opts); err != nil || out.String() != expected {
+			t.Errorf("RepairStreamWithOptions(%q) = %q, %v, want %q", input, out.String(), err, expected)
+		}
+
+		r := NewRepairerWithOptions(opts)
+		for i := 0; i < len(input); i++ {
+			r.WriteString(input[i : i+1])
+			prefix := input[:i+1]
+			expected, expectedErr := JSONRepairWithOptions(prefix, opts)
+			result, err := r.Snapshot()
+			if (err == nil) != (expectedErr == nil) || result != expected {
+				t.Fatalf("Snapshot after %q = %q, %v, want %q, %v", prefix, result, err, expected, expectedErr)
+			}
+		}
+	}
+}
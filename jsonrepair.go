@@ -35,8 +35,7 @@ package jsonrepair
 //   - Concatenate strings
 //   - Turn newline delimited JSON into a valid JSON array
 func JSONRepair(text string) (string, error) {
-	parser := NewParser(text)
-	return parser.Parse()
+	return repairString(text, Options{})
 }
 
 // MustJSONRepair repairs a string containing an invalid JSON document.
@@ -1,11 +1,14 @@
 package jsonrepair
 
 import (
+	"errors"
+	"fmt"
 	"strings"
 	"testing"
 )
 
-// assertRepair is a helper function that checks if the repair returns the same text
+// assertRepair is a helper function that checks if the repair returns the same text,
+// both with and without the fast path for valid JSON
 func assertRepair(t *testing.T, text string) {
 	t.Helper()
 	result, err := JSONRepair(text)
@@ -16,6 +19,14 @@ func assertRepair(t *testing.T, text string) {
 	if result != text {
 		t.Errorf("JSONRepair(%q) = %q, want %q", text, result, text)
 	}
+	result, err = JSONRepairWithOptions(text, Options{DisableFastPath: true})
+	if err != nil {
+		t.Errorf("JSONRepairWithOptions(%q) without fast path returned error: %v", text, err)
+		return
+	}
+	if result != text {
+		t.Errorf("JSONRepairWithOptions(%q) without fast path = %q, want %q", text, result, text)
+	}
 }
 
 // TestParseValidJSON tests parsing valid JSON (should pass through unchanged)
@@ -46,6 +57,8 @@ func TestParseValidJSON(t *testing.T) {
 		assertRepair(t, "[{}]")
 		assertRepair(t, `{"a":[]}`)
 		assertRepair(t, `[1, "hi", true, false, null, {}, []]`)
+		assertRepair(t, `["a",true,"b",null,"c"]`)
+		assertRepair(t, `{"a":"b","c":false,"d":"e"}`)
 	})
 
 	t.Run("parse number", func(t *testing.T) {
@@ -734,6 +747,27 @@ func TestRepairInvalidJSON(t *testing.T) {
 		}
 	})
 
+	t.Run("should repair triple quoted strings", func(t *testing.T) {
+		tests := []struct{ input, expected string }{
+			{`'''hello'''`, `"hello"`},
+			{`"""hello"""`, `"hello"`},
+			{"{\"a\": '''it's \"quoted\"\nand multi-line'''}", `{"a": "it's \"quoted\"\nand multi-line"}`},
+			{"[\"\"\"a 'b' \"c\"\"\"\", 2]", `["a 'b' \"c\"", 2]`},
+			{`{'''key''': """value"""}`, `{"key": "value"}`},
+			{`'''a\'b\n\u2605'''`, `"a'b\n\u2605"`},
+			{`'''escaped \''' quotes'''`, `"escaped ''' quotes"`},
+			{"'''\n\tindented\n'''", `"\n\tindented\n"`},
+			{`"""a""" + 'b'`, `"ab"`},
+			{`''''''`, `""`},
+		}
+		for _, tt := range tests {
+			result, err := JSONRepair(tt.input)
+			if err != nil || result != tt.expected {
+				t.Errorf("JSONRepair(%q) = %q, %v, want %q", tt.input, result, err, tt.expected)
+			}
+		}
+	})
+
 	t.Run("should leave string content untouched", func(t *testing.T) {
 		result, _ := JSONRepair(`"{a:b}"`)
 		if result != `"{a:b}"` {
@@ -971,6 +1005,33 @@ func TestRepairInvalidJSON(t *testing.T) {
 		}
 	})
 
+	t.Run("should end a string before a comma and a keyword or unquoted string", func(t *testing.T) {
+		// A quote followed by a comma and an identifier that ends at a
+		// delimiter is an end quote, even when the parser cannot take the
+		// fast path for valid JSON
+		opts := Options{DisableFastPath: true}
+		result, _ := JSONRepairWithOptions(`["a",true,"b",null,"c"]`, opts)
+		if result != `["a",true,"b",null,"c"]` {
+			t.Errorf("Expected %q, got %q", `["a",true,"b",null,"c"]`, result)
+		}
+
+		result, _ = JSONRepairWithOptions(`{"a":"b","c":false,"d":"e"}`, opts)
+		if result != `{"a":"b","c":false,"d":"e"}` {
+			t.Errorf("Expected %q, got %q", `{"a":"b","c":false,"d":"e"}`, result)
+		}
+
+		result, _ = JSONRepair(`["a",b,"c"]`)
+		if result != `["a","b","c"]` {
+			t.Errorf("Expected %q, got %q", `["a","b","c"]`, result)
+		}
+
+		// A quote followed by a comma and other text is still suspicious
+		result, _ = JSONRepair(`{"text": "x"y,z"}`)
+		if result != `{"text": "x\"y,z"}` {
+			t.Errorf("Expected %q, got %q", `{"text": "x\"y,z"}`, result)
+		}
+	})
+
 	t.Run("should replace special white space characters", func(t *testing.T) {
 		result, _ := JSONRepair("{\"a\":\u00a0\"foo\u00a0bar\"}")
 		if result != "{\"a\": \"foo\u00a0bar\"}" {
@@ -1045,6 +1106,11 @@ func TestRepairInvalidJSON(t *testing.T) {
 		if result != `{"flag":true}` {
 			t.Errorf("Expected %q, got %q", `{"flag":true}`, result)
 		}
+
+		result, _ = JSONRepair(`{"a": 1, /* truncated`)
+		if result != `{"a": 1} ` {
+			t.Errorf("Expected %q, got %q", `{"a": 1} `, result)
+		}
 	})
 
 	t.Run("should remove line comments", func(t *testing.T) {
@@ -1135,6 +1201,12 @@ func TestRepairInvalidJSON(t *testing.T) {
 			t.Errorf("Expected %q, got %q", `{}`, result)
 		}
 
+		// Without a first argument it is no function call
+		result, _ = JSONRepair("[foo(,1]")
+		if result != `["foo(",1]` {
+			t.Errorf("Expected %q, got %q", `["foo(",1]`, result)
+		}
+
 		result, _ = JSONRepair("/* foo bar */ callback_123 ({})")
 		if result != " {}" {
 			t.Errorf("Expected %q, got %q", " {}", result)
@@ -1325,6 +1397,17 @@ func TestRepairInvalidJSON(t *testing.T) {
 		if result != `"[1,2,3,]"` {
 			t.Errorf("Expected %q, got %q", `"[1,2,3,]"`, result)
 		}
+
+		// The comma before an empty nested array or object is kept
+		result, _ = JSONRepair("[1,[}")
+		if result != `[1,[]]` {
+			t.Errorf("Expected %q, got %q", `[1,[]]`, result)
+		}
+
+		result, _ = JSONRepair("[1,{]")
+		if result != `[1,{}]` {
+			t.Errorf("Expected %q, got %q", `[1,{}]`, result)
+		}
 	})
 
 	t.Run("should strip trailing commas from an object", func(t *testing.T) {
@@ -1940,9 +2023,8 @@ func TestJSONRepairErrors(t *testing.T) {
 		if err == nil {
 			t.Error("Expected error for empty string")
 		}
-		// Error message includes position info in Go implementation
-		if !strings.Contains(err.Error(), "Unexpected end of json string") {
-			t.Errorf("Expected error containing 'Unexpected end of json string', got %q", err.Error())
+		if !errors.Is(err, ErrUnexpectedEnd) {
+			t.Errorf("Expected ErrUnexpectedEnd, got %v", err)
 		}
 	})
 
@@ -1968,55 +2050,56 @@ func TestJSONRepairErrors(t *testing.T) {
 
 	t.Run("should throw an exception for unexpected character after valid JSON", func(t *testing.T) {
 		_, err := JSONRepair(`{"a":2}{}`)
-		if err == nil {
-			t.Error("Expected error for unexpected character")
+		if !errors.Is(err, ErrUnexpectedCharacter) {
+			t.Errorf("Expected ErrUnexpectedCharacter, got %v", err)
 		}
 
 		_, err = JSONRepair(`{"a":2}foo`)
-		if err == nil {
-			t.Error("Expected error for unexpected character 'foo' after valid JSON")
+		if !errors.Is(err, ErrUnexpectedCharacter) {
+			t.Errorf("Expected ErrUnexpectedCharacter for 'foo' after valid JSON, got %v", err)
 		}
 
 		_, err = JSONRepair(`foo [`)
-		if err == nil {
-			t.Error("Expected error for unexpected character '[' after unquoted string")
+		if !errors.Is(err, ErrUnexpectedCharacter) {
+			t.Errorf("Expected ErrUnexpectedCharacter for '[' after unquoted string, got %v", err)
 		}
 	})
 
 	t.Run("should throw an exception for invalid unicode", func(t *testing.T) {
-		// Note: Current Go implementation may repair some invalid unicode instead of throwing error
 		// Input: "\u26" (invalid unicode with only 2 hex digits)
-		result, err := JSONRepair("\"\\u26\"")
-		if err == nil {
-			// Go implementation repaired it - acceptable behavior
-			t.Logf("Go implementation repaired invalid unicode to %q", result)
-		}
+		_, err := JSONRepair("\"\\u26\"")
+		assertJSONRepairError(t, err, ErrInvalidUnicode, `Invalid unicode character "\\u26\""`, 1)
 
 		// Input: "\uZ000" (invalid unicode with non-hex character)
-		result, err = JSONRepair("\"\\uZ000\"")
-		if err == nil {
-			// Go implementation repaired it - acceptable behavior
-			t.Logf("Go implementation repaired invalid unicode to %q", result)
-		}
+		_, err = JSONRepair("\"\\uZ000\"")
+		assertJSONRepairError(t, err, ErrInvalidUnicode, `Invalid unicode character "\\uZ000"`, 1)
 	})
 
 	t.Run("should throw an exception for invalid control characters", func(t *testing.T) {
 		// Input: "abc\u0000" (null character)
-		result, err := JSONRepair("\"abc\x00\"")
-		if err == nil {
-			// Some implementations may repair it instead
-			t.Logf("Go implementation handled null character, result: %q", result)
-		}
+		_, err := JSONRepair("\"abc\x00\"")
+		assertJSONRepairError(t, err, ErrInvalidCharacter, `Invalid character '\x00'`, 4)
 
 		// Input: "abc\u001f" (control character)
-		result, err = JSONRepair("\"abc\x1f\"")
-		if err == nil {
-			// Some implementations may repair it instead
-			t.Logf("Go implementation handled control character 0x1f, result: %q", result)
-		}
+		_, err = JSONRepair("\"abc\x1f\"")
+		assertJSONRepairError(t, err, ErrInvalidCharacter, `Invalid character '\x1f'`, 4)
 	})
 }
 
+// assertJSONRepairError checks that err is a *JSONRepairError matching
+// target with the given message and position
+func assertJSONRepairError(t *testing.T, err error, target error, message string, position int) {
+	t.Helper()
+	var repairErr *JSONRepairError
+	if !errors.As(err, &repairErr) || !errors.Is(err, target) {
+		t.Errorf("Expected %v, got %v", target, err)
+		return
+	}
+	if repairErr.Message != message || repairErr.Position != position {
+		t.Errorf("Expected %q at position %d, got %q at position %d", message, position, repairErr.Message, repairErr.Position)
+	}
+}
+
 func TestMustJSONRepair(t *testing.T) {
 	t.Run("successful repair", func(t *testing.T) {
 		input := "{name: 'John'}"
@@ -2068,3 +2151,75 @@ func BenchmarkJSONRepair(b *testing.B) {
 		})
 	}
 }
+
+// BenchmarkJSONRepairScaling repairs large broken documents of growing size.
+// The time per byte should stay about the same as the documents grow.
+func BenchmarkJSONRepairScaling(b *testing.B) {
+	generators := []struct {
+		name     string
+		generate func(n int) string
+	}{
+		{
+			name: "missing commas",
+			generate: func(n int) string {
+				return "[" + strings.Repeat(`{"a": 1} `, n) + "]"
+			},
+		},
+		{
+			name: "missing colons",
+			generate: func(n int) string {
+				var sb strings.Builder
+				sb.WriteString("{")
+				for i := 0; i < n; i++ {
+					fmt.Fprintf(&sb, "\"key%d\" %d,\n", i, i)
+				}
+				sb.WriteString("}")
+				return sb.String()
+			},
+		},
+		{
+			name: "unquoted keys",
+			generate: func(n int) string {
+				var sb strings.Builder
+				sb.WriteString("{")
+				for i := 0; i < n; i++ {
+					fmt.Fprintf(&sb, "key%d: 'value', ", i)
+				}
+				sb.WriteString("}")
+				return sb.String()
+			},
+		},
+		{
+			name: "trailing commas",
+			generate: func(n int) string {
+				return strings.Repeat("[1, 2, 3,], ", n) + "[]"
+			},
+		},
+		{
+			name: "newline delimited",
+			generate: func(n int) string {
+				return strings.Repeat("{\"id\": 1, \"tags\": [\"a\", \"b\"]}\n", n)
+			},
+		},
+		{
+			name: "single quotes",
+			generate: func(n int) string {
+				return "[" + strings.Repeat(`'text', `, n) + "]"
+			},
+		},
+	}
+
+	for _, g := range generators {
+		for _, n := range []int{1000, 10000, 100000} {
+			input := g.generate(n)
+			b.Run(fmt.Sprintf("%s/%d", g.name, n), func(b *testing.B) {
+				b.SetBytes(int64(len(input)))
+				for i := 0; i < b.N; i++ {
+					if _, err := JSONRepair(input); err != nil {
+						b.Fatal(err)
+					}
+				}
+			})
+		}
+	}
+}
@@ -0,0 +1,101 @@
+package jsonrepair
+
+import (
+	"bytes"
+	"encoding"
+	"encoding/json"
+	"reflect"
+)
+
+// Lenient holds a value of type T that is repaired before it is decoded.
+// Use it for a field of a struct that is decoded with encoding/json to
+// repair just that field:
+//
+//	type Message struct {
+//		ID      string                  `json:"id"`
+//		Payload jsonrepair.Lenient[Tool] `json:"payload"`
+//	}
+//
+// A JSON string holding a stringified document, like "{name: 'John'}", is
+// repaired and decoded as that document, unless T is decoded from a string
+// itself.
+type Lenient[T any] struct {
+	Value T
+}
+
+// UnmarshalJSON implements json.Unmarshaler
+func (l *Lenient[T]) UnmarshalJSON(data []byte) error {
+	if bytes.Equal(data, []byte("null")) {
+		return nil
+	}
+	if doc, ok := stringifiedJSON(data, reflect.TypeOf((*T)(nil)).Elem()); ok {
+		data = doc
+	}
+	return Unmarshal(data, &l.Value)
+}
+
+// MarshalJSON implements json.Marshaler
+func (l Lenient[T]) MarshalJSON() ([]byte, error) {
+	return json.Marshal(l.Value)
+}
+
+// RawRepaired is a repaired raw JSON value, like json.RawMessage. A JSON
+// string holding a stringified object or array is replaced by the repaired
+// object or array.
+type RawRepaired []byte
+
+// UnmarshalJSON implements json.Unmarshaler
+func (r *RawRepaired) UnmarshalJSON(data []byte) error {
+	if doc, ok := stringifiedJSON(data, nil); ok {
+		data = doc
+	}
+	repaired, err := JSONRepair(string(data))
+	if err != nil {
+		return err
+	}
+	*r = RawRepaired(repaired)
+	return nil
+}
+
+// MarshalJSON implements json.Marshaler
+func (r RawRepaired) MarshalJSON() ([]byte, error) {
+	if r == nil {
+		return []byte("null"), nil
+	}
+	return r, nil
+}
+
+var (
+	jsonUnmarshalerType = reflect.TypeOf((*json.Unmarshaler)(nil)).Elem()
+	textUnmarshalerType = reflect.TypeOf((*encoding.TextUnmarshaler)(nil)).Elem()
+)
+
+// stringifiedJSON returns the contents of data when it is a JSON string
+// holding a document to be decoded into a value of type t. A nil t stands
+// for any type, in which case only objects and arrays are accepted.
+func stringifiedJSON(data []byte, t reflect.Type) ([]byte, bool) {
+	if len(data) == 0 || data[0] != '"' {
+		return nil, false
+	}
+	var s string
+	if err := json.Unmarshal(data, &s); err != nil {
+		return nil, false
+	}
+	doc := bytes.TrimSpace([]byte(s))
+
+	for t != nil && t.Kind() == reflect.Pointer {
+		t = t.Elem()
+	}
+	switch {
+	case t == nil || t.Kind() == reflect.Interface:
+		// Only a container is certain not to be meant as a string
+		return doc, len(doc) > 0 && (doc[0] == '{' || doc[0] == '[')
+	case t.Kind() == reflect.String,
+		t.Kind() == reflect.Slice && t.Elem().Kind() == reflect.Uint8,
+		reflect.PointerTo(t).Implements(jsonUnmarshalerType),
+		reflect.PointerTo(t).Implements(textUnmarshalerType):
+		// Values like strings, base64 encoded bytes and time.Time
+		return nil, false
+	}
+	return doc, true
+}
@@ -0,0 +1,109 @@
+package jsonrepair
+
+import (
+	"encoding/json"
+	"reflect"
+	"testing"
+	"time"
+)
+
+type lenientTool struct {
+	Name string         `json:"name"`
+	Args map[string]any `json:"args"`
+}
+
+func TestLenient(t *testing.T) {
+	var message struct {
+		ID      string               `json:"id"`
+		Payload Lenient[lenientTool] `json:"payload"`
+		Count   Lenient[int]         `json:"count"`
+		Text    Lenient[string]      `json:"text"`
+		Time    Lenient[time.Time]   `json:"time"`
+		Any     Lenient[any]         `json:"any"`
+		Missing Lenient[*int]        `json:"missing"`
+	}
+	input := `{
+		"id": "1",
+		"payload": "{name: 'search', args: {query: 'go', limit: 5,}",
+		"count": "5",
+		"text": "{not: json}",
+		"time": "2024-01-02T03:04:05Z",
+		"any": "[1, 2",
+		"missing": null
+	}`
+	if err := json.Unmarshal([]byte(input), &message); err != nil {
+		t.Fatalf("json.Unmarshal returned error: %v", err)
+	}
+
+	expectedTool := lenientTool{Name: "search", Args: map[string]any{"query": "go", "limit": 5.0}}
+	if !reflect.DeepEqual(message.Payload.Value, expectedTool) {
+		t.Errorf("Payload = %+v, want %+v", message.Payload.Value, expectedTool)
+	}
+	if message.Count.Value != 5 {
+		t.Errorf("Count = %v, want 5", message.Count.Value)
+	}
+	if message.Text.Value != "{not: json}" {
+		t.Errorf("Text = %q, want the string unchanged", message.Text.Value)
+	}
+	if !message.Time.Value.Equal(time.Date(2024, 1, 2, 3, 4, 5, 0, time.UTC)) {
+		t.Errorf("Time = %v", message.Time.Value)
+	}
+	if !reflect.DeepEqual(message.Any.Value, []any{1.0, 2.0}) {
+		t.Errorf("Any = %#v, want [1 2]", message.Any.Value)
+	}
+	if message.Missing.Value != nil {
+		t.Errorf("Missing = %v, want nil", message.Missing.Value)
+	}
+
+	output, err := json.Marshal(message.Payload)
+	if err != nil || string(output) != `{"name":"search","args":{"limit":5,"query":"go"}}` {
+		t.Errorf("json.Marshal(Payload) = %s, %v", output, err)
+	}
+}
+
+func TestLenientWithUnmarshal(t *testing.T) {
+	var values []Lenient[[]int]
+	if err := Unmarshal([]byte(`["[1, 2", [3 4], '[5]']`), &values); err != nil {
+		t.Fatalf("Unmarshal returned error: %v", err)
+	}
+	for i, expected := range [][]int{{1, 2}, {3, 4}, {5}} {
+		if !reflect.DeepEqual(values[i].Value, expected) {
+			t.Errorf("values[%d] = %v, want %v", i, values[i].Value, expected)
+		}
+	}
+}
+
+func TestRawRepaired(t *testing.T) {
+	var message struct {
+		Object RawRepaired `json:"object"`
+		String RawRepaired `json:"string"`
+		Text   RawRepaired `json:"text"`
+		Null   RawRepaired `json:"null"`
+	}
+	input := `{"object": {"a": 1}, "string": "{a: [1, 2", "text": "hello", "null": null}`
+	if err := json.Unmarshal([]byte(input), &message); err != nil {
+		t.Fatalf("json.Unmarshal returned error: %v", err)
+	}
+
+	tests := []struct {
+		name     string
+		actual   RawRepaired
+		expected string
+	}{
+		{"object", message.Object, `{"a": 1}`},
+		{"string", message.String, `{"a": [1, 2]}`},
+		{"text", message.Text, `"hello"`},
+		{"null", message.Null, `null`},
+	}
+	for _, tt := range tests {
+		if string(tt.actual) != tt.expected {
+			t.Errorf("%s = %s, want %s", tt.name, tt.actual, tt.expected)
+		}
+	}
+
+	output, err := json.Marshal(message)
+	expected := `{"object":{"a":1},"string":{"a":[1,2]},"text":"hello","null":null}`
+	if err != nil || string(output) != expected {
+		t.Errorf("json.Marshal = %s, %v; want %s", output, err, expected)
+	}
+}
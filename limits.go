@@ -0,0 +1,49 @@
+package jsonrepair
+
+// checkDepth aborts parsing when entering a container at the current
+// position would nest containers deeper than allowed
+func (p *Parser) checkDepth() {
+	if limit := p.depthLimit(); limit > 0 && len(p.stack) >= limit {
+		p.fail(p.newError(CodeDepthExceeded, "Maximum depth exceeded", p.offset+p.i))
+	}
+}
+
+// depthLimit returns the maximum nesting of containers, none when not
+// positive
+func (p *Parser) depthLimit() int {
+	if p.maxDepth == 0 {
+		return DefaultMaxDepth
+	}
+	return p.maxDepth
+}
+
+// checkInputSize aborts parsing when the input read so far is larger than
+// allowed
+func (p *Parser) checkInputSize() {
+	if err := p.inputSizeError(); err != nil {
+		p.fail(err)
+	}
+}
+
+// inputSizeError returns an error when the input read so far is larger than
+// allowed, nil otherwise
+func (p *Parser) inputSizeError() error {
+	if p.maxInput > 0 && p.offset+len(p.text) > p.maxInput {
+		return p.newError(CodeInputTooLarge, "Maximum input size exceeded", p.maxInput)
+	}
+	return nil
+}
+
+// checkStringLength aborts parsing when the string starting at start has
+// become longer than allowed
+func (p *Parser) checkStringLength(start int) {
+	if p.maxString > 0 && p.i-start > p.maxString {
+		p.fail(p.newError(CodeStringTooLong, "Maximum string length exceeded", p.offset+start))
+	}
+}
+
+// outputExceeded aborts parsing when the output is about to grow larger
+// than allowed
+func (p *Parser) outputExceeded() {
+	p.fail(p.newError(CodeOutputTooLarge, "Maximum output size exceeded", p.offset+p.i))
+}
@@ -0,0 +1,128 @@
+package jsonrepair
+
+import (
+	"bytes"
+	"errors"
+	"strings"
+	"testing"
+	"testing/iotest"
+)
+
+func TestMaxDepth(t *testing.T) {
+	deep := strings.Repeat("[", DefaultMaxDepth) + strings.Repeat("]", DefaultMaxDepth)
+	if _, err := JSONRepair(deep); err != nil {
+		t.Errorf("JSONRepair of %d nested arrays failed: %v", DefaultMaxDepth, err)
+	}
+
+	hostile := strings.Repeat("[", 1000000)
+	_, err := JSONRepair(hostile)
+	var repairErr *JSONRepairError
+	if !errors.As(err, &repairErr) || !errors.Is(err, ErrDepthExceeded) || repairErr.Position != DefaultMaxDepth {
+		t.Errorf("JSONRepair of hostile nesting returned %v", err)
+	}
+	if err := RepairStream(strings.NewReader(hostile), &bytes.Buffer{}); !errors.Is(err, ErrDepthExceeded) {
+		t.Errorf("RepairStream of hostile nesting returned %v", err)
+	}
+
+	tests := []struct {
+		input    string
+		maxDepth int
+		position int
+	}{
+		{`{"a": [1, {"b": 2}]}`, 2, 10},
+		{`[[[]]]`, 2, 2},
+		{`{"a": NumberLong(2)}`, 1, 17},
+		{`{a: {b: {c: 1`, 2, 8},
+	}
+	for _, tt := range tests {
+		_, err := JSONRepairWithOptions(tt.input, Options{MaxDepth: tt.maxDepth})
+		if !errors.As(err, &repairErr) || repairErr.Code != CodeDepthExceeded || repairErr.Position != tt.position {
+			t.Errorf("JSONRepairWithOptions(%q, MaxDepth %d) error = %v, want position %d", tt.input, tt.maxDepth, err, tt.position)
+		}
+		if _, err := JSONRepairWithOptions(tt.input, Options{MaxDepth: tt.maxDepth + 1}); err != nil {
+			t.Errorf("JSONRepairWithOptions(%q, MaxDepth %d) error: %v", tt.input, tt.maxDepth+1, err)
+		}
+	}
+
+	if _, err := JSONRepairWithOptions(strings.Repeat("[", DefaultMaxDepth+1), Options{MaxDepth: -1}); err != nil {
+		t.Errorf("Unexpected error without a depth limit: %v", err)
+	}
+}
+
+func TestMaxInputBytes(t *testing.T) {
+	opts := Options{MaxInputBytes: 10}
+	if _, err := JSONRepairWithOptions(`[1, 2, 3]`, opts); err != nil {
+		t.Errorf("Unexpected error: %v", err)
+	}
+
+	input := `[1, 2, 3, 4, 5]`
+	_, err := JSONRepairWithOptions(input, opts)
+	var repairErr *JSONRepairError
+	if !errors.As(err, &repairErr) || !errors.Is(err, ErrInputTooLarge) || repairErr.Position != 10 {
+		t.Errorf("JSONRepairWithOptions(%q) error = %v", input, err)
+	}
+
+	var out bytes.Buffer
+	err = RepairStreamWithOptions(iotest.OneByteReader(strings.NewReader(input)), &out, opts)
+	if !errors.As(err, &repairErr) || !errors.Is(err, ErrInputTooLarge) || repairErr.Position != 10 {
+		t.Errorf("RepairStreamWithOptions(%q) error = %v", input, err)
+	}
+
+	r := NewRepairerWithOptions(opts)
+	r.WriteString(input[:8])
+	if _, err := r.Snapshot(); err != nil {
+		t.Errorf("Unexpected error: %v", err)
+	}
+	r.WriteString(input[8:])
+	if _, err := r.Snapshot(); !errors.Is(err, ErrInputTooLarge) {
+		t.Errorf("Snapshot error = %v", err)
+	}
+}
+
+func TestMaxOutputBytes(t *testing.T) {
+	opts := Options{MaxOutputBytes: 12}
+	if result, err := JSONRepairWithOptions(`{a: 1}`, opts); err != nil || result != `{"a": 1}` {
+		t.Errorf("Unexpected result %q, %v", result, err)
+	}
+
+	tests := []struct {
+		input string
+		opts  Options
+	}{
+		{`{abc: 1, def: 2}`, opts},
+		{`[1, 2, 3, 4, 5, 6, 7]`, opts},
+		{`{"a": 1, "a": 2}`, Options{MaxOutputBytes: 25, DuplicateKeys: DuplicateKeysArray, Format: FormatIndent}},
+	}
+	for _, tt := range tests {
+		if _, err := JSONRepairWithOptions(tt.input, tt.opts); !errors.Is(err, ErrOutputTooLarge) {
+			t.Errorf("JSONRepairWithOptions(%q) error = %v", tt.input, err)
+		}
+		var out bytes.Buffer
+		if err := RepairStreamWithOptions(strings.NewReader(tt.input), &out, tt.opts); !errors.Is(err, ErrOutputTooLarge) {
+			t.Errorf("RepairStreamWithOptions(%q) error = %v", tt.input, err)
+		}
+	}
+}
+
+func TestMaxStringLength(t *testing.T) {
+	opts := Options{MaxStringLength: 8}
+	if _, err := JSONRepairWithOptions(`["abcdef", ghijkl]`, opts); err != nil {
+		t.Errorf("Unexpected error: %v", err)
+	}
+
+	tests := []struct {
+		input    string
+		position int
+	}{
+		{`["abc", "abcdefghijkl"]`, 8},
+		{`{"key": 'abcdefghijkl`, 8},
+		{`[abcdefghijkl]`, 1},
+	}
+	for _, tt := range tests {
+		_, err := JSONRepairWithOptions(tt.input, opts)
+		var repairErr *JSONRepairError
+		if !errors.As(err, &repairErr) || !errors.Is(err, ErrStringTooLong) || repairErr.Position != tt.position {
+			t.Errorf("JSONRepairWithOptions(%q) error = %v, want position %d", tt.input, err, tt.position)
+		}
+	}
+}
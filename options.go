@@ -0,0 +1,280 @@
+package jsonrepair
+
+import "io"
+
+// RepairKind identifies a category of repair the parser can apply
+type RepairKind int
+
+const (
+	RepairMarkdownFence      RepairKind = iota + 1 // Stripped a markdown code fence like ```json
+	RepairComment                                  // Stripped a /* block */ or // line comment
+	RepairSpecialWhitespace                        // Replaced a special whitespace character by a space
+	RepairMissingComma                             // Inserted a missing comma
+	RepairLeadingComma                             // Stripped a comma before the first member
+	RepairTrailingComma                            // Stripped a comma after the last member
+	RepairMissingColon                             // Inserted a missing colon after a key
+	RepairMissingValue                             // Inserted null for a missing object value
+	RepairMissingBracket                           // Closed an object or array missing its end bracket
+	RepairRedundantBracket                         // Stripped a redundant end bracket
+	RepairNewlineDelimited                         // Wrapped newline delimited JSON in an array
+	RepairEllipsis                                 // Stripped an ellipsis like [1, 2, ...]
+	RepairQuotes                                   // Replaced single or special quotes by double quotes
+	RepairMissingQuote                             // Inserted a missing end quote
+	RepairUnescapedQuote                           // Escaped a quote inside a string
+	RepairControlCharacter                         // Escaped a control character inside a string
+	RepairInvalidEscape                            // Stripped an invalid or truncated escape sequence
+	RepairEscapedString                            // Unescaped a string like \"text\"
+	RepairConcatenation                            // Concatenated strings like "a" + "b"
+	RepairNumber                                   // Completed a truncated number or quoted one with leading zeros
+	RepairPythonConstant                           // Replaced None, True or False
+	RepairUnquotedString                           // Added quotes around an unquoted key or string
+	RepairUndefined                                // Replaced undefined by null
+	RepairFunctionCall                             // Stripped a function call like NumberLong(2) or callback(...)
+	RepairRegex                                    // Turned a regular expression into a string
+	RepairTypeCoercion                             // Converted a value to the type of its Go field or schema
+	RepairWrapArray                                // Wrapped a single value into an array for a Go slice or schema
+	RepairMissingField                             // Added a missing required field of a Go struct
+	RepairUnknownField                             // Removed a key matching no field of a Go struct
+	RepairEnum                                     // Replaced a value by the nearest value allowed by a schema enum
+	RepairDefault                                  // Added a missing required property with its schema default
+	RepairAdditionalProperty                       // Removed a property forbidden by a schema
+	RepairDuplicateKey                             // Resolved a key appearing more than once in an object
+	RepairPythonString                             // Stripped a Python string prefix like b'' or converted an escape like \x41
+	RepairPythonValue                              // Converted a Python tuple, set or value like float('nan') or Decimal('1.2')
+	RepairJSON5String                              // Converted a JSON5 escape like \x41 or a line continuation
+	RepairJSON5Number                              // Converted a JSON5 number like 0x1F, .5, +1 or Infinity
+)
+
+var repairKindNames = map[RepairKind]string{
+	RepairMarkdownFence:      "markdown fence",
+	RepairComment:            "comment",
+	RepairSpecialWhitespace:  "special whitespace",
+	RepairMissingComma:       "missing comma",
+	RepairLeadingComma:       "leading comma",
+	RepairTrailingComma:      "trailing comma",
+	RepairMissingColon:       "missing colon",
+	RepairMissingValue:       "missing value",
+	RepairMissingBracket:     "missing bracket",
+	RepairRedundantBracket:   "redundant bracket",
+	RepairNewlineDelimited:   "newline delimited JSON",
+	RepairEllipsis:           "ellipsis",
+	RepairQuotes:             "quotes",
+	RepairMissingQuote:       "missing quote",
+	RepairUnescapedQuote:     "unescaped quote",
+	RepairControlCharacter:   "control character",
+	RepairInvalidEscape:      "invalid escape",
+	RepairEscapedString:      "escaped string",
+	RepairConcatenation:      "string concatenation",
+	RepairNumber:             "number",
+	RepairPythonConstant:     "Python constant",
+	RepairUnquotedString:     "unquoted string",
+	RepairUndefined:          "undefined",
+	RepairFunctionCall:       "function call",
+	RepairRegex:              "regular expression",
+	RepairTypeCoercion:       "type coercion",
+	RepairWrapArray:          "wrapped array",
+	RepairMissingField:       "missing field",
+	RepairUnknownField:       "unknown field",
+	RepairEnum:               "enum",
+	RepairDefault:            "default",
+	RepairAdditionalProperty: "additional property",
+	RepairDuplicateKey:       "duplicate key",
+	RepairPythonString:       "Python string",
+	RepairPythonValue:        "Python value",
+	RepairJSON5String:        "JSON5 string",
+	RepairJSON5Number:        "JSON5 number",
+}
+
+// String returns a short description of the repair kind
+func (k RepairKind) String() string {
+	if name, ok := repairKindNames[k]; ok {
+		return name
+	}
+	return "unknown repair"
+}
+
+// Format selects how whitespace is written to the repaired document
+type Format int
+
+const (
+	FormatPreserve Format = iota // Keep the whitespace of the input
+	FormatCompact                // Write no whitespace between tokens
+	FormatIndent                 // Write each member on its own line, indented by Options.Indent
+
+	// FormatCanonical writes the document like Canonicalize does. The keys
+	// are sorted once the whole document is repaired, so a stream is only
+	// written at its end, and the output offsets of repairs refer to the
+	// document before its keys were sorted.
+	FormatCanonical
+)
+
+// Dialect selects the language the input is written in, on top of the
+// JSON-like syntax that is always repaired
+type Dialect int
+
+const (
+	DialectJSON   Dialect = iota // JSON with the repairs of JavaScript-like syntax, comments and Python constants
+	DialectPython                // The repr() of Python values, like tuples, sets, b'' strings and Decimal('1.2')
+	DialectJSON5                 // JSON5, with hexadecimal numbers, escapes like \x41 and identifier keys
+)
+
+// NonFinite selects how NaN and Infinity are written, as JSON has no
+// numbers for them
+type NonFinite int
+
+const (
+	NonFiniteNull   NonFinite = iota // Write null
+	NonFiniteString                  // Write the strings "NaN", "Infinity" and "-Infinity"
+	NonFiniteError                   // Fail with a *JSONRepairError at the number
+)
+
+// DuplicateKeys selects how keys appearing more than once in an object are
+// resolved
+type DuplicateKeys int
+
+const (
+	DuplicateKeysAllow DuplicateKeys = iota // Keep every member
+	DuplicateKeysFirst                      // Keep the first member with the key
+	DuplicateKeysLast                       // Keep the last member with the key
+	DuplicateKeysMerge                      // Merge objects recursively into the first member, otherwise keep the last value there
+	DuplicateKeysArray                      // Collect the values into an array in the first member
+	DuplicateKeysError                      // Fail with a *JSONRepairError at the second key
+)
+
+// Options configures how a document is repaired. The zero value applies
+// every repair and keeps the whitespace of the input, like JSONRepair does.
+type Options struct {
+	// Disable lists the repairs that must not be applied. When the input
+	// can only be repaired by one of them, repairing fails with a
+	// *JSONRepairError at the position that needed it.
+	Disable []RepairKind
+
+	// Format selects how whitespace is written. It is applied while
+	// repairing, so the output is not parsed a second time.
+	Format Format
+
+	// Indent is the indentation of one level with FormatIndent, two
+	// spaces when empty
+	Indent string
+
+	// Dialect selects the language the input is written in. With
+	// DialectPython, tuples and sets become arrays, string prefixes and
+	// escapes are converted, float('nan') and float('inf') are written
+	// according to NonFinite, and Decimal and datetime values become
+	// numbers and ISO 8601 strings. With DialectJSON5, the numbers,
+	// escapes, identifier keys and whitespace of JSON5 are converted to
+	// JSON.
+	//
+	// The \N{name} escapes of DialectPython are converted for these names,
+	// in any case, and kept as they are otherwise:
+	//   - LATIN CAPITAL LETTER A to Z, LATIN SMALL LETTER A to Z and DIGIT
+	//     ZERO to NINE
+	//   - GREEK CAPITAL LETTER and GREEK SMALL LETTER followed by ALPHA to
+	//     OMEGA, and GREEK SMALL LETTER FINAL SIGMA
+	//   - CJK UNIFIED IDEOGRAPH- followed by the hexadecimal code point
+	//   - SPACE, NO-BREAK SPACE, ZERO WIDTH SPACE, ZERO WIDTH NON-JOINER,
+	//     ZERO WIDTH JOINER, ZERO WIDTH NO-BREAK SPACE, EN DASH, EM DASH,
+	//     HORIZONTAL ELLIPSIS, BULLET, LEFT SINGLE QUOTATION MARK, RIGHT
+	//     SINGLE QUOTATION MARK, LEFT DOUBLE QUOTATION MARK, RIGHT DOUBLE
+	//     QUOTATION MARK, DEGREE SIGN, COPYRIGHT SIGN, REGISTERED SIGN,
+	//     TRADE MARK SIGN, SECTION SIGN, PILCROW SIGN, MICRO SIGN, CENT
+	//     SIGN, POUND SIGN, YEN SIGN, EURO SIGN, PLUS-MINUS SIGN,
+	//     MULTIPLICATION SIGN, DIVISION SIGN, INFINITY, NOT EQUAL TO,
+	//     LESS-THAN OR EQUAL TO, GREATER-THAN OR EQUAL TO, LEFTWARDS ARROW,
+	//     RIGHTWARDS ARROW, CHECK MARK, HEAVY CHECK MARK, BALLOT X, BLACK
+	//     STAR, WHITE STAR, SNOWMAN, BLACK HEART SUIT, HEAVY BLACK HEART,
+	//     GRINNING FACE, FACE WITH TEARS OF JOY, THUMBS UP SIGN and
+	//     REPLACEMENT CHARACTER
+	Dialect Dialect
+
+	// NonFinite selects how NaN and Infinity of the JSON5 and Python
+	// dialects are written
+	NonFinite NonFinite
+
+	// DuplicateKeys selects how keys appearing more than once in an object
+	// are resolved. Duplicates are resolved once the whole document is
+	// repaired, so with a policy other than DuplicateKeysAllow a stream is
+	// only written at its end. Output that is still not valid JSON is left
+	// as it is.
+	DuplicateKeys DuplicateKeys
+
+	// Limits for untrusted input. Repairing fails with a *JSONRepairError
+	// as soon as one is exceeded. MaxDepth is the maximum nesting of
+	// objects, arrays and function calls: zero means DefaultMaxDepth and a
+	// negative value no limit. For the others, zero means no limit.
+	MaxDepth        int
+	MaxInputBytes   int // Maximum size of the input
+	MaxOutputBytes  int // Maximum size of the repaired document
+	MaxStringLength int // Maximum length of a string in the input, in bytes
+
+	// DisableFastPath parses input that is already strict JSON like any
+	// other input. Otherwise such input is recognized in a single pass
+	// over its bytes and returned as is, when the output would be the
+	// same: with FormatPreserve and DuplicateKeysAllow. Streams and
+	// Repairer snapshots are always parsed.
+	DisableFastPath bool
+}
+
+// DefaultMaxDepth is the maximum nesting of objects, arrays and function
+// calls when Options.MaxDepth is zero, and for JSONRepair and RepairStream.
+// It keeps hostile input like [[[[... from exhausting the stack.
+const DefaultMaxDepth = 10000
+
+// JSONRepairWithOptions repairs a string containing an invalid JSON
+// document, applying only the repairs allowed by opts.
+//
+// Example:
+//
+//	// Fix trailing commas, but refuse to turn several values into an array
+//	opts := jsonrepair.Options{Disable: []jsonrepair.RepairKind{jsonrepair.RepairNewlineDelimited}}
+//	repaired, err := jsonrepair.JSONRepairWithOptions(text, opts)
+func JSONRepairWithOptions(text string, opts Options) (string, error) {
+	return repairString(text, opts)
+}
+
+// NewParserWithOptions creates a new Parser instance applying only the
+// repairs allowed by opts
+func NewParserWithOptions(text string, opts Options) *Parser {
+	parser := NewParser(text)
+	parser.setOptions(opts)
+	return parser
+}
+
+// RepairStreamWithOptions is like RepairStream, applying only the repairs
+// allowed by opts
+func RepairStreamWithOptions(r io.Reader, w io.Writer, opts Options) error {
+	return repairStream(r, w, opts)
+}
+
+// NewRepairerWithOptions creates a Repairer applying only the repairs
+// allowed by opts
+func NewRepairerWithOptions(opts Options) *Repairer {
+	return &Repairer{opts: opts}
+}
+
+// setOptions configures the parser according to opts
+func (p *Parser) setOptions(opts Options) {
+	p.disabled = 0
+	for _, kind := range opts.Disable {
+		p.disabled |= 1 << kind
+	}
+	p.format = opts.Format
+	p.dialect = opts.Dialect
+	p.nonFinite = opts.NonFinite
+	p.duplicates = opts.DuplicateKeys
+	p.maxDepth = opts.MaxDepth
+	p.maxInput = opts.MaxInputBytes
+	p.maxOutput = opts.MaxOutputBytes
+	p.output.max = opts.MaxOutputBytes
+	p.maxString = opts.MaxStringLength
+	p.fastPathDisabled = opts.DisableFastPath
+	p.indent = opts.Indent
+	if p.indent == "" {
+		p.indent = "  "
+	}
+}
+
+// allowed reports whether repairs of the given kind may be applied
+func (p *Parser) allowed(kind RepairKind) bool {
+	return p.disabled&(1<<kind) == 0
+}
@@ -0,0 +1,145 @@
+package jsonrepair
+
+import (
+	"bytes"
+	"errors"
+	"strings"
+	"testing"
+)
+
+func TestJSONRepairWithOptionsDefault(t *testing.T) {
+	for _, input := range streamTestInputs {
+		expected, expectedErr := JSONRepair(input)
+		result, err := JSONRepairWithOptions(input, Options{})
+		if result != expected || (err == nil) != (expectedErr == nil) {
+			t.Errorf("JSONRepairWithOptions(%q) = %q, %v; want %q, %v", input, result, err, expected, expectedErr)
+		}
+	}
+}
+
+func TestJSONRepairWithOptions(t *testing.T) {
+	tests := []struct {
+		name     string
+		input    string
+		disable  []RepairKind
+		expected string
+	}{
+		{"fix trailing commas but not NDJSON", `[1, 2, 3,]`, []RepairKind{RepairNewlineDelimited}, `[1, 2, 3]`},
+		{"valid JSON with everything disabled", `{"a": [1, 2.5, "x", true, null]}`, allRepairKinds(), `{"a": [1, 2.5, "x", true, null]}`},
+		{"quotes allowed", `{'a': 'b'}`, []RepairKind{RepairUnquotedString}, `{"a": "b"}`},
+		{"ellipsis with trailing comma disabled", `[1, 2, ...]`, []RepairKind{RepairTrailingComma}, ""},
+		{"repairs of a retried string are not counted", "[\"a, b\tc,", []RepairKind{RepairControlCharacter}, `["a", "b\tc"]`},
+		{"skipped function arguments are not counted", `Timestamp(1234, 'x')`, []RepairKind{RepairQuotes}, `1234`},
+		{"inserted comma taken back", `[1, 2 ]`, []RepairKind{RepairMissingComma}, `[1, 2 ]`},
+		{"NDJSON without missing comma", "{\"id\":1}\n{\"id\":2}", []RepairKind{RepairMissingComma}, "[\n{\"id\":1},\n{\"id\":2}\n]"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			result, err := JSONRepairWithOptions(tt.input, Options{Disable: tt.disable})
+			if tt.expected == "" {
+				if err == nil {
+					t.Errorf("expected an error, got %q", result)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+			if result != tt.expected {
+				t.Errorf("got %q, want %q", result, tt.expected)
+			}
+		})
+	}
+}
+
+func TestJSONRepairWithOptionsErrors(t *testing.T) {
+	tests := []struct {
+		input    string
+		disable  RepairKind
+		message  string
+		position int
+	}{
+		{"{\"id\":1}\n{\"id\":2}", RepairNewlineDelimited, "Repair disabled: newline delimited JSON", 9},
+		{`{name: "John"}`, RepairUnquotedString, "Repair disabled: unquoted string", 1},
+		{`{"a": 1 "b": 2}`, RepairMissingComma, "Repair disabled: missing comma", 8},
+		{`[1, 2,]`, RepairTrailingComma, "Repair disabled: trailing comma", 5},
+		{`{"a": [1, 2`, RepairMissingBracket, "Repair disabled: missing bracket", 11},
+		{`{"a": "b`, RepairMissingQuote, "Repair disabled: missing quote", 8},
+		{`{"a": None}`, RepairPythonConstant, "Repair disabled: Python constant", 6},
+		{`// note` + "\n" + `[1]`, RepairComment, "Repair disabled: comment", 0},
+		{`[2.]`, RepairNumber, "Repair disabled: number", 3},
+	}
+
+	for _, tt := range tests {
+		_, err := JSONRepairWithOptions(tt.input, Options{Disable: []RepairKind{tt.disable}})
+		var repairErr *JSONRepairError
+		if !errors.As(err, &repairErr) {
+			t.Errorf("JSONRepairWithOptions(%q): expected *JSONRepairError, got %v", tt.input, err)
+			continue
+		}
+		if repairErr.Message != tt.message || repairErr.Position != tt.position {
+			t.Errorf("JSONRepairWithOptions(%q) error = %q at %d; want %q at %d",
+				tt.input, repairErr.Message, repairErr.Position, tt.message, tt.position)
+		}
+	}
+}
+
+func TestRepairStreamWithOptions(t *testing.T) {
+	for _, kind := range allRepairKinds() {
+		opts := Options{Disable: []RepairKind{kind}}
+		for _, input := range streamTestInputs {
+			expected, expectedErr := JSONRepairWithOptions(input, opts)
+
+			var out bytes.Buffer
+			err := RepairStreamWithOptions(strings.NewReader(input), &out, opts)
+			if expectedErr != nil {
+				if err == nil || err.Error() != expectedErr.Error() {
+					t.Errorf("RepairStreamWithOptions(%q, %v) error = %v; want %v", input, kind, err, expectedErr)
+				}
+				continue
+			}
+			if err != nil || out.String() != expected {
+				t.Errorf("RepairStreamWithOptions(%q, %v) = %q, %v; want %q", input, kind, out.String(), err, expected)
+			}
+		}
+	}
+}
+
+func TestRepairerWithOptions(t *testing.T) {
+	for _, kind := range allRepairKinds() {
+		opts := Options{Disable: []RepairKind{kind}}
+		for _, input := range streamTestInputs {
+			r := NewRepairerWithOptions(opts)
+			for i := 0; i < len(input); i++ {
+				r.WriteString(input[i : i+1])
+				prefix := input[:i+1]
+				expected, expectedErr := JSONRepairWithOptions(prefix, opts)
+				result, err := r.Snapshot()
+				if (err == nil) != (expectedErr == nil) || result != expected {
+					t.Fatalf("Snapshot after %q with %v disabled = %q, %v; want %q, %v",
+						prefix, kind, result, err, expected, expectedErr)
+				}
+			}
+		}
+	}
+}
+
+func TestRepairKindString(t *testing.T) {
+	for _, kind := range allRepairKinds() {
+		if kind.String() == "unknown repair" {
+			t.Errorf("RepairKind(%d) has no name", kind)
+		}
+	}
+	if RepairKind(0).String() != "unknown repair" {
+		t.Errorf("RepairKind(0).String() = %q", RepairKind(0).String())
+	}
+}
+
+func allRepairKinds() []RepairKind {
+	var kinds []RepairKind
+	for kind := RepairMarkdownFence; kind <= RepairRegex; kind++ {
+		kinds = append(kinds, kind)
+	}
+	return append(kinds, RepairPythonString, RepairPythonValue, RepairJSON5String, RepairJSON5Number)
+}
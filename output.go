@@ -0,0 +1,247 @@
+package jsonrepair
+
+import (
+	"bytes"
+	"io"
+	"slices"
+	"strings"
+	"unicode/utf8"
+	"unsafe"
+)
+
+// outputBuffer collects the repaired output. When w is set, output that can
+// no longer be rewritten is flushed to w, so that only a tail of the output
+// is kept in memory. Positions passed to and returned by its methods count
+// from the start of the whole output, including the flushed part.
+//
+// Whitespace is kept in pending until the next token is written, so that
+// most repairs, like inserting a missing comma before it, only append to
+// the buffer. Rewriting output costs the length of the output after the
+// rewritten position, which is short for the repairs of the parser.
+type outputBuffer struct {
+	buf     []byte
+	frozen  int       // Length of buf shared with strings returned by String
+	flushed int       // Number of bytes already written to w
+	w       io.Writer // Destination of flushed output, nil to keep everything
+	err     error     // First error returned by w
+
+	// edited is called after removed bytes at position pos were replaced by
+	// inserted bytes anywhere but at the end of the output
+	edited func(pos, removed, inserted int)
+
+	// pending is the whitespace to write before the next token. It holds
+	// the whitespace of the input, or when the output is formatted, the
+	// whitespace of the format while that of the input is left out.
+	pending string
+	open    bool // Whether the innermost container has no member written yet
+	newline bool // Whether whitespace left out since the last write had a newline
+
+	// exceeded is called before the output grows beyond max bytes, when
+	// max is positive
+	max      int
+	exceeded func()
+}
+
+// WriteString appends s to the output, after the pending whitespace
+func (o *outputBuffer) WriteString(s string) {
+	o.grow(len(s))
+	o.writePending()
+	o.buf = append(o.buf, s...)
+}
+
+// WriteRune appends r to the output, after the pending whitespace
+func (o *outputBuffer) WriteRune(r rune) {
+	o.grow(utf8.RuneLen(r))
+	o.writePending()
+	o.buf = utf8.AppendRune(o.buf, r)
+}
+
+// grow checks that n more bytes and the pending whitespace fit in the
+// output, and prepares the buffer for appending
+func (o *outputBuffer) grow(n int) {
+	if o.max > 0 && o.next()+n > o.max && o.exceeded != nil {
+		o.exceeded()
+	}
+	o.unfreeze(len(o.buf))
+}
+
+// unfreeze copies the buffer before the bytes from offset n on change, when
+// they are shared with a string returned by String
+func (o *outputBuffer) unfreeze(n int) {
+	if n < o.frozen {
+		o.buf = slices.Clone(o.buf)
+		o.frozen = 0
+	}
+}
+
+// writePending writes the pending whitespace before a token
+func (o *outputBuffer) writePending() {
+	if o.pending != "" {
+		o.buf = append(o.buf, o.pending...)
+		o.pending = ""
+	}
+	o.open = false
+	o.newline = false
+}
+
+// addWhitespace adds whitespace of the input to write before the next token
+func (o *outputBuffer) addWhitespace(whitespace string) {
+	if o.pending == "" {
+		o.pending = whitespace
+	} else {
+		o.pending += whitespace
+	}
+}
+
+// leaveOut notes whitespace of the input that is left out of the output
+func (o *outputBuffer) leaveOut(whitespace string) {
+	if strings.Contains(whitespace, "\n") {
+		o.newline = true
+	}
+}
+
+// next returns the position where the next token will be written
+func (o *outputBuffer) next() int {
+	return o.Len() + len(o.pending)
+}
+
+// endsWithCommaOrNewline reports whether the output ends with a comma or a
+// newline, optionally followed by whitespace, counting the pending
+// whitespace and whitespace that was left out
+func (o *outputBuffer) endsWithCommaOrNewline() bool {
+	if o.newline {
+		return true
+	}
+	if found, ok := lastCommaOrNewline(o.pending); ok {
+		return found
+	}
+	found, _ := lastCommaOrNewline(unsafe.String(unsafe.SliceData(o.buf), len(o.buf)))
+	return found
+}
+
+// lastCommaOrNewline scans text backwards over spaces, tabs and carriage
+// returns. It reports whether the character before them is a comma or a
+// newline, and ok is false when text holds nothing else.
+func lastCommaOrNewline(text string) (found, ok bool) {
+	for j := len(text) - 1; j >= 0; j-- {
+		switch text[j] {
+		case ' ', '\t', '\r':
+		case ',', '\n':
+			return true, true
+		default:
+			return false, true
+		}
+	}
+	return false, false
+}
+
+// Len returns the total length of the output, without pending whitespace
+func (o *outputBuffer) Len() int {
+	return o.flushed + len(o.buf)
+}
+
+// String returns the part of the output that has not been flushed yet. The
+// buffer is copied before it changes, so the string remains valid.
+func (o *outputBuffer) String() string {
+	if len(o.buf) == 0 {
+		return ""
+	}
+	o.frozen = len(o.buf)
+	return unsafe.String(&o.buf[0], len(o.buf))
+}
+
+// since returns the output after position n, which must not be flushed
+func (o *outputBuffer) since(n int) string {
+	return string(o.buf[n-o.flushed:])
+}
+
+// view returns the output after position n, which must not be flushed,
+// without copying it. It is only valid until the output changes.
+func (o *outputBuffer) view(n int) []byte {
+	return o.buf[n-o.flushed:]
+}
+
+// truncate discards all output after position n
+func (o *outputBuffer) truncate(n int) {
+	o.buf = o.buf[:min(max(n-o.flushed, 0), len(o.buf))]
+}
+
+// replace replaces removed bytes at offset n of the unflushed output by
+// text, moving only the output after them
+func (o *outputBuffer) replace(n, removed int, text string) {
+	o.grow(len(text) - removed)
+	o.unfreeze(n)
+	o.buf = slices.Replace(o.buf, n, n+removed, []byte(text)...)
+	if o.edited != nil {
+		o.edited(o.flushed+n, removed, len(text))
+	}
+}
+
+// insertAt inserts text at position n
+func (o *outputBuffer) insertAt(n int, text string) {
+	o.replace(min(max(n-o.flushed, 0), len(o.buf)), 0, text)
+}
+
+// removeAt removes count bytes starting at position n
+func (o *outputBuffer) removeAt(n, count int) {
+	n -= o.flushed
+	if n < 0 || n >= len(o.buf) {
+		return
+	}
+	o.replace(n, min(count, len(o.buf)-n), "")
+}
+
+// insertBeforeLastWhitespace inserts text before the trailing whitespace
+// and returns the position where it was inserted. The pending whitespace
+// stays after text.
+func (o *outputBuffer) insertBeforeLastWhitespace(text string) int {
+	n := len(o.buf)
+	for n > 0 && isWhitespace(unsafe.String(&o.buf[0], n), n-1) {
+		_, size := utf8.DecodeLastRune(o.buf[:n])
+		n -= size
+	}
+	if n == len(o.buf) {
+		o.grow(len(text))
+		o.buf = append(o.buf, text...)
+	} else {
+		o.replace(n, 0, text)
+	}
+	return o.flushed + n
+}
+
+// stripLastOccurrence removes the last occurrence of text, and everything
+// after it including the pending whitespace if stripRemainingText is set.
+// It returns the position where text was found, or -1 when it was not.
+func (o *outputBuffer) stripLastOccurrence(text string, stripRemainingText bool) int {
+	n := bytes.LastIndex(o.buf, []byte(text))
+	if n == -1 {
+		return -1
+	}
+	if stripRemainingText {
+		o.replace(n, len(o.buf)-n, "")
+		o.pending = ""
+	} else {
+		o.replace(n, len(text), "")
+	}
+	return o.flushed + n
+}
+
+// unshift inserts text at the start of the output, which must not be
+// flushed yet
+func (o *outputBuffer) unshift(text string) {
+	o.replace(0, 0, text)
+}
+
+// flush writes all but the last keep bytes of the output to w
+func (o *outputBuffer) flush(keep int) {
+	if o.w == nil || len(o.buf) <= keep {
+		return
+	}
+	n := len(o.buf) - keep
+	if o.err == nil {
+		_, o.err = o.w.Write(o.buf[:n])
+	}
+	o.flushed += n
+	o.unfreeze(0)
+	o.buf = o.buf[:copy(o.buf, o.buf[n:])]
+}
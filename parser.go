@@ -1,7 +1,9 @@
 package jsonrepair
 
 import (
+	"bytes"
 	"encoding/json"
+	"slices"
 	"strconv"
 	"strings"
 	"unicode/utf8"
@@ -9,45 +11,132 @@ import (
 
 // Parse parses and repairs the JSON text
 func (p *Parser) Parse() (string, error) {
+	if p.unchanged() {
+		return p.text, nil
+	}
+	if err := p.parse(); err != nil {
+		return "", err
+	}
+	return p.result()
+}
+
+// result returns the repaired document once duplicate keys are resolved,
+// canonicalized with FormatCanonical
+func (p *Parser) result() (string, error) {
+	if err := p.finish(); err != nil {
+		return "", err
+	}
+	return p.output.String(), nil
+}
+
+// finish completes the repaired document in the output buffer, resolving
+// duplicate keys and canonicalizing it with FormatCanonical
+func (p *Parser) finish() error {
+	p.output.max = 0 // Checked below once the document is complete
+	p.writeTrailingWhitespace()
+	if p.duplicates != DuplicateKeysAllow {
+		if err := p.resolveDuplicates(); err != nil {
+			return err
+		}
+	}
+
+	if p.format == FormatCanonical {
+		canonical, err := Canonicalize(p.output.buf)
+		if err != nil {
+			return p.newError(CodeNotCanonical, "Cannot canonicalize: "+err.Error(), p.offset+p.i)
+		}
+		p.output.buf, p.output.frozen = canonical, 0
+	}
+	if p.maxOutput > 0 && len(p.output.buf) > p.maxOutput {
+		return p.newError(CodeOutputTooLarge, "Maximum output size exceeded", p.offset+p.i)
+	}
+	return nil
+}
+
+// writeTrailingWhitespace writes the whitespace at the end of the input,
+// which is kept in the output unless it is formatted
+func (p *Parser) writeTrailingWhitespace() {
+	if p.format == FormatPreserve {
+		p.output.writePending()
+	}
+}
+
+// buffered reports whether the output is only final once the whole
+// document is repaired
+func (p *Parser) buffered() bool {
+	return p.format == FormatCanonical || p.duplicates != DuplicateKeysAllow
+}
+
+// hookOutput connects the output buffer to the parser. The hooks are kept
+// when a pooled parser is reused, as creating them allocates.
+func (p *Parser) hookOutput() {
+	if p.output.edited == nil {
+		p.output.edited = p.shiftRepairs
+		p.output.exceeded = p.outputExceeded
+	}
+}
+
+// parse repairs the input, leaving the result in p.output
+func (p *Parser) parse() (err error) {
+	defer p.recoverBailout(&err)
+	p.hookOutput()
+	p.checkInputSize()
+
 	// Parse optional markdown code block at the start
 	p.parseMarkdownCodeBlock([]string{"```", "[```", "{```"})
 
 	// Parse the main value
 	processed := p.parseValue()
 	if !processed {
-		return "", p.throwUnexpectedEnd()
+		p.checkRepairs()
+		return p.throwUnexpectedEnd()
 	}
+	if p.extracting {
+		// Leave the text after the value
+		p.output.pending = ""
+		p.checkRepairs()
+		return nil
+	}
+
+	return p.parseRootEnd()
+}
 
+// parseRootEnd parses what may follow the main value
+func (p *Parser) parseRootEnd() error {
 	// Parse optional markdown code block at the end (BEFORE checking for NDJSON)
 	p.parseMarkdownCodeBlock([]string{"```", "```]", "```}"})
 
 	// Handle trailing comma
 	p.parseWhitespaceAndSkipComments(true)
-	processedComma := p.parseCharacter(',')
+	processedComma := p.parseComma()
 	if processedComma {
 		p.parseWhitespaceAndSkipComments(true)
 	}
 
 	// Check for newline delimited JSON
-	if p.i < len(p.text) && isStartOfValue(p.text, p.i) && endsWithCommaOrNewline(p.output.String()) {
+	if p.has(p.i) && isStartOfValue(p.text, p.i) && p.output.endsWithCommaOrNewline() {
 		if !processedComma {
 			// Repair missing comma
-			output := p.output.String()
-			p.output.Reset()
-			p.output.WriteString(insertBeforeLastWhitespace(output, ","))
+			p.insert(RepairNewlineDelimited, ",")
+		}
+		if err := p.parseNewlineDelimitedJSON(); err != nil {
+			return err
 		}
-		p.parseNewlineDelimitedJSON()
 	} else if processedComma {
 		// Remove trailing comma
-		output := p.output.String()
-		p.output.Reset()
-		p.output.WriteString(stripLastOccurrence(output, ",", false))
+		p.stripTrailingComma(-1)
 	}
 
+	return p.parseEnd()
+}
+
+// parseEnd skips redundant end brackets and checks that all input is parsed
+func (p *Parser) parseEnd() error {
 	// Repair redundant end quotes
-	for p.i < len(p.text) {
+	for p.has(p.i) {
 		r, _ := getCharAt(p.text, p.i)
 		if r == '}' || r == ']' {
+			p.repair(RepairRedundantBracket, p.i, p.text[p.i:p.i+1], "")
 			p.i++
 			p.parseWhitespaceAndSkipComments(true)
 		} else {
@@ -55,12 +144,14 @@ func (p *Parser) Parse() (string, error) {
 		}
 	}
 
+	p.checkRepairs()
+
 	// Check if we've reached the end
-	if p.i >= len(p.text) {
-		return p.output.String(), nil
+	if !p.has(p.i) {
+		return nil
 	}
 
-	return "", p.throwUnexpectedCharacter()
+	return p.throwUnexpectedCharacter()
 }
 
 // parseValue parses any JSON value
@@ -68,9 +159,11 @@ func (p *Parser) parseValue() bool {
 	p.parseWhitespaceAndSkipComments(true)
 	processed := p.parseObject() ||
 		p.parseArray() ||
+		p.parseTuple() ||
 		p.parseString(false, -1) ||
 		p.parseNumber() ||
 		p.parseKeywords() ||
+		p.parsePythonValue() ||
 		p.parseUnquotedString(false) ||
 		p.parseRegex()
 	p.parseWhitespaceAndSkipComments(true)
@@ -79,6 +172,12 @@ func (p *Parser) parseValue() bool {
 
 // parseWhitespaceAndSkipComments parses whitespace and skips comments
 func (p *Parser) parseWhitespaceAndSkipComments(skipNewline bool) bool {
+	if p.has(p.i) {
+		if c := p.text[p.i]; c > ' ' && c < utf8.RuneSelf && c != '/' {
+			// Neither whitespace nor a comment
+			return false
+		}
+	}
 	start := p.i
 	p.parseWhitespace(skipNewline)
 	for {
@@ -95,51 +194,80 @@ func (p *Parser) parseWhitespaceAndSkipComments(skipNewline bool) bool {
 
 // parseWhitespace parses whitespace characters
 func (p *Parser) parseWhitespace(skipNewline bool) bool {
-	var whitespace strings.Builder
+	start := p.i
+	var replaced []byte // Whitespace with special whitespace replaced, when there is any
 
-	for p.i < len(p.text) {
-		if skipNewline && isWhitespace(p.text, p.i) {
-			r, size := utf8.DecodeRuneInString(p.text[p.i:])
-			whitespace.WriteRune(r)
-			p.i += size
-		} else if !skipNewline && isWhitespaceExceptNewline(p.text, p.i) {
-			r, size := utf8.DecodeRuneInString(p.text[p.i:])
-			whitespace.WriteRune(r)
+	for p.has(p.i) {
+		if c := p.text[p.i]; c > ' ' && c < utf8.RuneSelf {
+			// No whitespace of any kind, which is by far the most common
+			break
+		}
+		r, size := utf8.DecodeRuneInString(p.text[p.i:])
+		if skipNewline && isWhitespace(p.text, p.i) || !skipNewline && isWhitespaceExceptNewline(p.text, p.i) {
+			if replaced != nil {
+				replaced = append(replaced, p.text[p.i:p.i+size]...)
+			}
 			p.i += size
-		} else if isSpecialWhitespace(p.text, p.i) {
+		} else if isSpecialWhitespace(p.text, p.i) || p.dialect == DialectJSON5 && isJSON5Whitespace(p.text, p.i) {
 			// Repair special whitespace
-			whitespace.WriteRune(' ')
-			r, size := utf8.DecodeRuneInString(p.text[p.i:])
-			_ = r
+			if replaced == nil {
+				replaced = append([]byte{}, p.text[start:p.i]...)
+			}
+			replacement := " "
+			if r == '\ufeff' {
+				// A byte order mark is left out rather than replaced
+				replacement = ""
+			}
+			fix := Repair{Kind: RepairSpecialWhitespace, InputOffset: p.offset + p.i, OutputOffset: p.output.Len(), Original: p.text[p.i : p.i+size]}
+			if p.format == FormatPreserve {
+				fix.OutputOffset = p.output.next() + len(replaced)
+				fix.Replacement = replacement
+			}
+			p.record(fix)
+			replaced = append(replaced, replacement...)
 			p.i += size
 		} else {
 			break
 		}
 	}
 
-	if whitespace.Len() > 0 {
-		p.output.WriteString(whitespace.String())
-		return true
+	if p.i == start {
+		return false
 	}
-	return false
+	whitespace := p.text[start:p.i]
+	if replaced != nil {
+		whitespace = string(replaced)
+	}
+	if p.format == FormatPreserve {
+		p.output.addWhitespace(whitespace)
+	} else {
+		p.output.leaveOut(whitespace)
+	}
+	return true
 }
 
 // parseComment parses and skips comments
 func (p *Parser) parseComment() bool {
 	// Block comment /* ... */
-	if p.i < len(p.text)-1 && p.text[p.i] == '/' && p.text[p.i+1] == '*' {
-		for p.i < len(p.text) && !atEndOfBlockComment(p.text, p.i) {
+	if p.has(p.i+1) && p.text[p.i] == '/' && p.text[p.i+1] == '*' {
+		start := p.i
+		for p.has(p.i) && !atEndOfBlockComment(p.text, p.i) {
 			p.i++
 		}
-		p.i += 2
+		if p.has(p.i) {
+			p.i += 2 // Skip the end of the comment, unless it is truncated
+		}
+		p.repair(RepairComment, start, p.text[start:p.i], "")
 		return true
 	}
 
 	// Line comment // ...
-	if p.i < len(p.text)-1 && p.text[p.i] == '/' && p.text[p.i+1] == '/' {
-		for p.i < len(p.text) && p.text[p.i] != '\n' {
+	if p.has(p.i+1) && p.text[p.i] == '/' && p.text[p.i+1] == '/' {
+		start := p.i
+		for p.has(p.i) && p.text[p.i] != '\n' {
 			p.i++
 		}
+		p.repair(RepairComment, start, p.text[start:p.i], "")
 		return true
 	}
 
@@ -148,12 +276,13 @@ func (p *Parser) parseComment() bool {
 
 // parseMarkdownCodeBlock parses and skips markdown code blocks
 func (p *Parser) parseMarkdownCodeBlock(blocks []string) bool {
-	if p.skipMarkdownCodeBlock(blocks) {
+	if block := p.skipMarkdownCodeBlock(blocks); block != "" {
+		start := p.i - len(block)
 		// Check for optional language specifier
-		if p.i < len(p.text) {
+		if p.has(p.i) {
 			r, _ := getCharAt(p.text, p.i)
 			if isFunctionNameCharStart(r) {
-				for p.i < len(p.text) {
+				for p.has(p.i) {
 					r, _ := getCharAt(p.text, p.i)
 					if isFunctionNameChar(r) {
 						p.i++
@@ -163,6 +292,7 @@ func (p *Parser) parseMarkdownCodeBlock(blocks []string) bool {
 				}
 			}
 		}
+		p.repair(RepairMarkdownFence, start, p.text[start:p.i], "")
 		// Skip whitespace and comments after the code block
 		p.parseWhitespaceAndSkipComments(true)
 		return true
@@ -170,23 +300,24 @@ func (p *Parser) parseMarkdownCodeBlock(blocks []string) bool {
 	return false
 }
 
-// skipMarkdownCodeBlock skips markdown code block markers
-func (p *Parser) skipMarkdownCodeBlock(blocks []string) bool {
+// skipMarkdownCodeBlock skips markdown code block markers and returns the
+// marker it skipped, if any
+func (p *Parser) skipMarkdownCodeBlock(blocks []string) string {
 	p.parseWhitespace(true)
 
 	for _, block := range blocks {
 		end := p.i + len(block)
-		if end <= len(p.text) && p.text[p.i:end] == block {
+		if p.has(end-1) && p.text[p.i:end] == block {
 			p.i = end
-			return true
+			return block
 		}
 	}
-	return false
+	return ""
 }
 
 // parseCharacter parses a specific character
 func (p *Parser) parseCharacter(char rune) bool {
-	if p.i < len(p.text) {
+	if p.has(p.i) {
 		r, size := utf8.DecodeRuneInString(p.text[p.i:])
 		if r == char {
 			p.output.WriteRune(r)
@@ -197,9 +328,19 @@ func (p *Parser) parseCharacter(char rune) bool {
 	return false
 }
 
+// parseComma parses a comma separating two values
+func (p *Parser) parseComma() bool {
+	pos := p.offset + p.i
+	if p.parseCharacter(',') {
+		p.comma = pos
+		return true
+	}
+	return false
+}
+
 // skipCharacter skips a specific character without outputting it
 func (p *Parser) skipCharacter(char rune) bool {
-	if p.i < len(p.text) {
+	if p.has(p.i) {
 		r, size := utf8.DecodeRuneInString(p.text[p.i:])
 		if r == char {
 			p.i += size
@@ -218,10 +359,14 @@ func (p *Parser) skipEscapeCharacter() bool {
 func (p *Parser) skipEllipsis() bool {
 	p.parseWhitespaceAndSkipComments(true)
 
-	if p.i+2 < len(p.text) && p.text[p.i] == '.' && p.text[p.i+1] == '.' && p.text[p.i+2] == '.' {
+	if p.has(p.i+2) && p.text[p.i] == '.' && p.text[p.i+1] == '.' && p.text[p.i+2] == '.' {
+		p.repair(RepairEllipsis, p.i, "...", "")
 		p.i += 3
 		p.parseWhitespaceAndSkipComments(true)
-		p.skipCharacter(',')
+		if p.has(p.i) && p.text[p.i] == ',' {
+			p.repair(RepairEllipsis, p.i, ",", "")
+			p.i++
+		}
 		return true
 	}
 	return false
@@ -229,7 +374,7 @@ func (p *Parser) skipEllipsis() bool {
 
 // parseObject parses a JSON object
 func (p *Parser) parseObject() bool {
-	if p.i >= len(p.text) {
+	if !p.has(p.i) {
 		return false
 	}
 
@@ -237,96 +382,114 @@ func (p *Parser) parseObject() bool {
 	if r != '{' {
 		return false
 	}
+	if p.dialect == DialectPython && p.atSet() {
+		return p.parseCollection('}')
+	}
+	p.checkDepth()
 
 	p.output.WriteRune('{')
 	p.i += size
 	p.parseWhitespaceAndSkipComments(true)
 
 	// Skip leading comma
-	if p.skipCharacter(',') {
+	if p.has(p.i) && p.text[p.i] == ',' {
+		p.repair(RepairLeadingComma, p.i, ",", "")
+		p.i++
 		p.parseWhitespaceAndSkipComments(true)
 	}
 
-	initial := true
-	for p.i < len(p.text) {
+	p.pushFrame(frameObject)
+	p.layout(true)
+	processed := p.parseObjectMembers(true)
+	p.popFrame()
+	return processed
+}
+
+// parseObjectMembers parses the members of an object up to and including
+// its end bracket. initial tells whether no member has been parsed yet.
+func (p *Parser) parseObjectMembers(initial bool) bool {
+	for p.has(p.i) {
+		p.checkContext()
+		p.safePoint(initial)
 		r, _ := getCharAt(p.text, p.i)
 		if r == '}' {
 			break
 		}
 
-		var processedComma bool
+		missingComma := -1
+		first := initial
 		if !initial {
-			processedComma = p.parseCharacter(',')
-			if !processedComma {
+			if !p.parseComma() {
 				// Repair missing comma
-				output := p.output.String()
-				p.output.Reset()
-				p.output.WriteString(insertBeforeLastWhitespace(output, ","))
+				missingComma = p.offset + p.i
+				p.insert(RepairMissingComma, ",")
 			}
+			p.layout(false)
 			p.parseWhitespaceAndSkipComments(true)
 		} else {
-			processedComma = true
 			initial = false
 		}
 
 		p.skipEllipsis()
 
+		keyStart, keyInput := p.output.Len(), p.offset+p.i
 		processedKey := p.parseString(false, -1) || p.parseUnquotedString(true)
+		if processedKey && p.duplicates != DuplicateKeysAllow {
+			p.keys = append(p.keys, keyInput)
+		}
 		if !processedKey {
+			atEnd := !p.has(p.i)
 			r, _ := getCharAt(p.text, p.i)
-			if r == '}' || r == '{' || r == ']' || r == '[' || p.i >= len(p.text) {
-				// Repair trailing comma
-				output := p.output.String()
-				p.output.Reset()
-				p.output.WriteString(stripLastOccurrence(output, ",", false))
+			if r == '}' || r == '{' || r == ']' || r == '[' || atEnd {
+				// Repair trailing comma. Before the first member the last
+				// comma is one of an enclosing array.
+				if !first {
+					p.stripTrailingComma(missingComma)
+				}
 			} else {
+				p.noteFailure()
 				return false
 			}
 			break
 		}
+		p.stack[len(p.stack)-1].key = p.writtenKey(keyStart, keyInput)
 
 		p.parseWhitespaceAndSkipComments(true)
 		processedColon := p.parseCharacter(':')
-		truncatedText := p.i >= len(p.text)
+		truncatedText := !p.has(p.i)
 
 		if !processedColon {
-			if p.i < len(p.text) && isStartOfValue(p.text, p.i) || truncatedText {
+			if p.has(p.i) && isStartOfValue(p.text, p.i) || truncatedText {
 				// Repair missing colon
-				output := p.output.String()
-				p.output.Reset()
-				p.output.WriteString(insertBeforeLastWhitespace(output, ":"))
+				p.insert(RepairMissingColon, ":")
 			} else {
+				p.noteFailure()
 				return false
 			}
 		}
+		if p.format == FormatIndent {
+			p.output.pending = " "
+		}
 
 		processedValue := p.parseValue()
 		if !processedValue {
 			if processedColon || truncatedText {
 				// Repair missing object value
+				p.repair(RepairMissingValue, p.i, "", "null")
 				p.output.WriteString("null")
 			} else {
+				p.noteFailure()
 				return false
 			}
 		}
 	}
 
-	if p.i < len(p.text) {
-		r, size := utf8.DecodeRuneInString(p.text[p.i:])
-		if r == '}' {
-			p.output.WriteRune('}')
-			p.i += size
-		} else {
-			// Repair missing end bracket
-			output := p.output.String()
-			p.output.Reset()
-			p.output.WriteString(insertBeforeLastWhitespace(output, "}"))
-		}
+	if p.has(p.i) && p.text[p.i] == '}' {
+		p.closeBracket("}", false)
+		p.i++
 	} else {
 		// Repair missing end bracket
-		output := p.output.String()
-		p.output.Reset()
-		p.output.WriteString(insertBeforeLastWhitespace(output, "}"))
+		p.closeBracket("}", true)
 	}
 
 	return true
@@ -334,7 +497,7 @@ func (p *Parser) parseObject() bool {
 
 // parseArray parses a JSON array
 func (p *Parser) parseArray() bool {
-	if p.i >= len(p.text) {
+	if !p.has(p.i) {
 		return false
 	}
 
@@ -342,85 +505,145 @@ func (p *Parser) parseArray() bool {
 	if r != '[' {
 		return false
 	}
+	p.checkDepth()
 
 	p.output.WriteRune('[')
 	p.i += size
 	p.parseWhitespaceAndSkipComments(true)
 
 	// Skip leading comma
-	if p.skipCharacter(',') {
+	if p.has(p.i) && p.text[p.i] == ',' {
+		p.repair(RepairLeadingComma, p.i, ",", "")
+		p.i++
 		p.parseWhitespaceAndSkipComments(true)
 	}
 
-	initial := true
-	for p.i < len(p.text) {
+	p.pushFrame(frameArray)
+	p.layout(true)
+	p.parseArrayMembers(true)
+	p.popFrame()
+	return true
+}
+
+// parseArrayMembers parses the items of an array up to and including its
+// end bracket. initial tells whether no item has been parsed yet. It
+// returns false when the array was closed by a repair before a character
+// that is no item rather than at its end bracket or the end of the input.
+func (p *Parser) parseArrayMembers(initial bool) bool {
+	closing := p.stack[len(p.stack)-1].closing
+	if closing == 0 {
+		closing = ']'
+	}
+	for p.has(p.i) {
+		p.checkContext()
+		p.safePoint(initial)
 		r, _ := getCharAt(p.text, p.i)
-		if r == ']' {
+		if r == rune(closing) {
 			break
 		}
 
+		missingComma := -1
+		first := initial
 		if !initial {
-			processedComma := p.parseCharacter(',')
+			processedComma := p.parseComma()
 			if !processedComma {
 				// Repair missing comma
-				output := p.output.String()
-				p.output.Reset()
-				p.output.WriteString(insertBeforeLastWhitespace(output, ","))
+				missingComma = p.offset + p.i
+				p.insert(RepairMissingComma, ",")
 			}
+			p.layout(false)
+			p.stack[len(p.stack)-1].index++
 		} else {
 			initial = false
 		}
 
 		p.skipEllipsis()
 
-		processedValue := p.parseValue()
+		// The end of a tuple does not end an unquoted string, so it is
+		// checked first
+		processedValue := closing != ')' || !p.has(p.i) || p.text[p.i] != ')'
+		processedValue = processedValue && p.parseValue()
 		if !processedValue {
-			// Repair trailing comma
-			output := p.output.String()
-			p.output.Reset()
-			p.output.WriteString(stripLastOccurrence(output, ",", false))
+			// Repair trailing comma. Before the first item the last comma
+			// is one of an enclosing array.
+			if !first {
+				p.stripTrailingComma(missingComma)
+			}
 			break
 		}
 	}
 
-	if p.i < len(p.text) {
-		r, size := utf8.DecodeRuneInString(p.text[p.i:])
-		if r == ']' {
-			p.output.WriteRune(']')
-			p.i += size
-		} else {
-			// Repair missing closing bracket
-			output := p.output.String()
-			p.output.Reset()
-			p.output.WriteString(insertBeforeLastWhitespace(output, "]"))
+	if p.has(p.i) && p.text[p.i] == closing {
+		p.closeBracket("]", false)
+		if closing != ']' {
+			p.record(Repair{Kind: RepairPythonValue, InputOffset: p.offset + p.i, OutputOffset: p.output.Len() - 1, Original: p.text[p.i : p.i+1], Replacement: "]"})
 		}
-	} else {
-		// Repair missing closing bracket
-		output := p.output.String()
-		p.output.Reset()
-		p.output.WriteString(insertBeforeLastWhitespace(output, "]"))
+		p.i++
+		return true
 	}
 
-	return true
+	// Repair missing closing bracket
+	p.closeBracket("]", true)
+	return !p.has(p.i)
 }
 
 // parseNewlineDelimitedJSON repairs newline delimited JSON
-func (p *Parser) parseNewlineDelimitedJSON() {
+func (p *Parser) parseNewlineDelimitedJSON() error {
 	// Note: The first value has already been parsed in Parse()
 	// and a comma has already been added if needed
 	// We just need to parse the remaining values
-	initial := true
+
+	// Wrap in array brackets. This happens before parsing the remaining
+	// values so that a streaming parser can keep flushing its output.
+	open := "[\n"
+	switch p.format {
+	case FormatCompact, FormatCanonical:
+		open = "["
+	case FormatIndent:
+		open = "[\n" + p.indent
+	}
+	if p.output.flushed > 0 {
+		return p.newError(CodeOutputFlushed, "Cannot wrap newline delimited JSON: output already flushed", p.offset+p.i)
+	}
+	if p.format == FormatIndent {
+		// Indent the first value one more level
+		text := p.output.String()
+		for j := len(text) - 1; j >= 0; j-- {
+			if text[j] == '\n' {
+				p.output.insertAt(j+1, p.indent)
+			}
+		}
+	}
+	p.output.unshift(open)
+	p.record(Repair{Kind: RepairNewlineDelimited, InputOffset: p.offset + p.i, Replacement: open})
+
+	// The first value has index 0
+	p.pushFrame(frameNewlineDelimited)
+	p.stack[len(p.stack)-1].index = 1
+	p.layout(false)
+	p.parseNewlineDelimitedValues(true)
+	p.popFrame()
+	return nil
+}
+
+// parseNewlineDelimitedValues parses the remaining values of newline
+// delimited JSON and closes the array wrapping them
+func (p *Parser) parseNewlineDelimitedValues(initial bool) {
 	processedValue := true
+	missingComma := -1
 
 	for processedValue {
+		p.safePoint(initial)
+		missingComma = -1
 		if !initial {
-			processedComma := p.parseCharacter(',')
+			processedComma := p.parseComma()
 			if !processedComma {
 				// Repair: add missing comma
-				output := p.output.String()
-				p.output.Reset()
-				p.output.WriteString(insertBeforeLastWhitespace(output, ","))
+				missingComma = p.offset + p.i
+				p.insert(RepairNewlineDelimited, ",")
 			}
+			p.layout(false)
+			p.stack[len(p.stack)-1].index++
 		} else {
 			initial = false
 		}
@@ -428,23 +651,28 @@ func (p *Parser) parseNewlineDelimitedJSON() {
 	}
 
 	// Remove trailing comma if any
-	output := p.output.String()
-	p.output.Reset()
-	p.output.WriteString(stripLastOccurrence(output, ",", false))
+	p.stripTrailingComma(missingComma)
 
-	// Wrap in array brackets
-	result := p.output.String()
-	p.output.Reset()
-	p.output.WriteString("[\n")
-	p.output.WriteString(result)
-	p.output.WriteString("\n]")
+	// Close the array bracket opened above
+	if p.format != FormatPreserve {
+		p.output.pending = ""
+	}
+	closing := "\n]"
+	if p.format == FormatCompact || p.format == FormatCanonical {
+		closing = "]"
+	}
+	p.repair(RepairNewlineDelimited, p.i, "", closing)
+	p.output.WriteString(closing)
 }
 
 // parseString parses a JSON string (to be continued in next part due to complexity)
 func (p *Parser) parseString(stopAtDelimiter bool, stopAtIndex int) bool {
-	if p.i >= len(p.text) {
+	if !p.has(p.i) {
 		return false
 	}
+	if p.parsePythonStringPrefix() {
+		defer func() { p.raw = false }()
+	}
 
 	// Check for escaped string
 	skipEscapeChars := false
@@ -453,7 +681,7 @@ func (p *Parser) parseString(stopAtDelimiter bool, stopAtIndex int) bool {
 		skipEscapeChars = true
 	}
 
-	if p.i >= len(p.text) {
+	if !p.has(p.i) {
 		return false
 	}
 
@@ -465,6 +693,11 @@ func (p *Parser) parseString(stopAtDelimiter bool, stopAtIndex int) bool {
 		return false
 	}
 
+	if !skipEscapeChars && p.parseTripleQuotedString() {
+		p.parseConcatenatedString()
+		return true
+	}
+
 	// Determine end quote function
 	var isEndQuote func(rune) bool
 	if isDoubleQuote(r) {
@@ -477,40 +710,43 @@ func (p *Parser) parseString(stopAtDelimiter bool, stopAtIndex int) bool {
 		isEndQuote = isDoubleQuoteLike
 	}
 
+	if skipEscapeChars {
+		p.repair(RepairEscapedString, p.i-1, "\\", "")
+	}
+
 	iBefore := p.i
-	oBefore := p.output.Len()
+	m := p.mark()
+
+	if !isDoubleQuote(r) {
+		p.repair(RepairQuotes, p.i, p.text[p.i:p.i+size], "\"")
+	}
 
 	p.output.WriteRune('"')
 	p.i += size
 
 	for {
-		if p.i >= len(p.text) {
+		p.checkContext()
+		p.checkStringLength(iBefore)
+		if !p.has(p.i) {
 			// Missing end quote
 			iPrev := p.prevNonWhitespaceIndex(p.i - 1)
-			if iPrev >= 0 && iPrev < len(p.text) {
+			if iPrev >= 0 && p.has(iPrev) {
 				prevR, _ := getCharAt(p.text, iPrev)
 				if !stopAtDelimiter && isDelimiter(prevR) {
 					// Retry parsing
-					p.i = iBefore
-					outputStr := p.output.String()
-					p.output.Reset()
-					p.output.WriteString(outputStr[:oBefore])
+					p.rollback(m)
 					return p.parseString(true, -1)
 				}
 			}
 
 			// Repair missing quote
-			currentOutput := p.output.String()
-			p.output.Reset()
-			p.output.WriteString(insertBeforeLastWhitespace(currentOutput, "\""))
+			p.insert(RepairMissingQuote, "\"")
 			return true
 		}
 
 		if p.i == stopAtIndex {
 			// Use stop index
-			currentOutput := p.output.String()
-			p.output.Reset()
-			p.output.WriteString(insertBeforeLastWhitespace(currentOutput, "\""))
+			p.insert(RepairMissingQuote, "\"")
 			return true
 		}
 
@@ -520,13 +756,15 @@ func (p *Parser) parseString(stopAtDelimiter bool, stopAtIndex int) bool {
 			// Potential end quote
 			iQuote := p.i
 			oQuote := p.output.Len()
+			q := p.mark()
 			p.output.WriteRune('"')
 			p.i += currentSize
 
 			p.parseWhitespaceAndSkipComments(false)
 
+			atEnd := !p.has(p.i)
 			nextR, _ := getCharAt(p.text, p.i)
-			if stopAtDelimiter || p.i >= len(p.text) ||
+			if stopAtDelimiter || atEnd ||
 				isDelimiter(nextR) || isQuote(nextR) || isDigit(nextR) {
 				// The quote is followed by the end of the text, a delimiter,
 				// or a next value. So the quote is indeed the end of the string.
@@ -541,9 +779,8 @@ func (p *Parser) parseString(stopAtDelimiter bool, stopAtIndex int) bool {
 					if validEndQuoteIndex != -1 {
 						// Found a valid end quote further ahead, so this quote is unescaped
 						// Remove the quote we wrote and write escaped quote instead
-						outputStr := p.output.String()
-						p.output.Reset()
-						p.output.WriteString(outputStr[:oQuote])
+						p.rollback(q)
+						p.repair(RepairUnescapedQuote, iQuote, p.text[iQuote:iQuote+currentSize], "\\\"")
 						p.output.WriteString("\\\"")
 						p.i = iQuote + currentSize
 						continue
@@ -551,50 +788,46 @@ func (p *Parser) parseString(stopAtDelimiter bool, stopAtIndex int) bool {
 				}
 
 				// Valid end quote
+				if currentR != '"' {
+					p.record(Repair{
+						Kind:         RepairQuotes,
+						InputOffset:  p.offset + iQuote,
+						OutputOffset: oQuote,
+						Original:     p.text[iQuote : iQuote+currentSize],
+						Replacement:  "\"",
+					})
+				}
 				p.parseConcatenatedString()
 				return true
 			}
 
 			iPrevChar := p.prevNonWhitespaceIndex(iQuote - 1)
-			if iPrevChar >= 0 && iPrevChar < len(p.text) {
+			if iPrevChar >= 0 && p.has(iPrevChar) {
 				prevChar, _ := getCharAt(p.text, iPrevChar)
 				if prevChar == ',' {
 					// Comma before quote - retry
-					p.i = iBefore
-					outputStr := p.output.String()
-					p.output.Reset()
-					p.output.WriteString(outputStr[:oBefore])
+					p.rollback(m)
 					return p.parseString(false, iPrevChar)
 				}
 
 				if isDelimiter(prevChar) {
 					// Delimiter before quote - retry
-					p.i = iBefore
-					outputStr := p.output.String()
-					p.output.Reset()
-					p.output.WriteString(outputStr[:oBefore])
+					p.rollback(m)
 					return p.parseString(true, -1)
 				}
 			}
 
-			// Not a real end quote, continue
-			outputStr := p.output.String()
-			p.output.Reset()
-			p.output.WriteString(outputStr[:oQuote+1])
+			// Not a real end quote, continue. Repair unescaped quote
+			p.rollback(q)
+			p.repair(RepairUnescapedQuote, iQuote, p.text[iQuote:iQuote+currentSize], "\\\"")
+			p.output.WriteString("\\\"")
 			p.i = iQuote + currentSize
 
-			// Repair unescaped quote - insert backslash at oQuote position
-			currentOutput := p.output.String()
-			p.output.Reset()
-			p.output.WriteString(currentOutput[:oQuote])
-			p.output.WriteString("\\")
-			p.output.WriteString(currentOutput[oQuote:])
-
 		} else if stopAtDelimiter && isUnquotedStringDelimiter(currentR) {
 			// Stop at delimiter
-			if p.i > 0 && p.text[p.i-1] == ':' && matchesUrlStart(p.text, iBefore+1, p.i+2) {
+			if p.i > 0 && p.text[p.i-1] == ':' && p.matchesUrlStart(iBefore+1, p.i+2) {
 				// Handle URL - write directly to output
-				for p.i < len(p.text) {
+				for p.has(p.i) {
 					r, size := utf8.DecodeRuneInString(p.text[p.i:])
 					if matchesUrlChar(r) {
 						p.output.WriteRune(r)
@@ -606,27 +839,31 @@ func (p *Parser) parseString(stopAtDelimiter bool, stopAtIndex int) bool {
 			}
 
 			// Repair missing quote
-			currentOutput := p.output.String()
-			p.output.Reset()
-			p.output.WriteString(insertBeforeLastWhitespace(currentOutput, "\""))
+			p.insert(RepairMissingQuote, "\"")
 			p.parseConcatenatedString()
 			return true
 
+		} else if currentR == '\\' && p.raw {
+			// Backslashes are literal in a Python raw string
+			p.repair(RepairPythonString, p.i, "\\", `\\`)
+			p.output.WriteString(`\\`)
+			p.i += currentSize
 		} else if currentR == '\\' {
 			// Handle escape sequences
-			if p.i+1 < len(p.text) {
+			if p.has(p.i + 1) {
 				nextChar, nextSize := utf8.DecodeRuneInString(p.text[p.i+1:])
 
 				// Check for truncated unicode: \\uXX or \\uXXX (less than 4 hex digits at end of text)
-				if nextChar == '\\' && p.i+2 < len(p.text) && p.text[p.i+2] == 'u' {
+				if nextChar == '\\' && p.has(p.i+2) && p.text[p.i+2] == 'u' {
 					// Potential truncated unicode escape
 					j := 3 // \, \, u already counted
-					for j < 7 && p.i+j < len(p.text) && isHex(rune(p.text[p.i+j])) {
+					for j < 7 && p.has(p.i+j) && isHex(rune(p.text[p.i+j])) {
 						j++
 					}
 					// If we're at end of text and have less than 6 chars total (\\uXXXX), it's truncated
-					if p.i+j >= len(p.text) && j < 7 {
+					if !p.has(p.i+j) && j < 7 {
 						// Truncated unicode - jump to end to trigger missing quote repair
+						p.repair(RepairInvalidEscape, p.i, p.text[p.i:], "")
 						p.i = len(p.text)
 						continue
 					}
@@ -639,25 +876,31 @@ func (p *Parser) parseString(stopAtDelimiter bool, stopAtIndex int) bool {
 				} else if nextChar == 'u' {
 					// Unicode escape
 					j := 2
-					for j < 6 && p.i+j < len(p.text) && isHex(rune(p.text[p.i+j])) {
+					for j < 6 && p.has(p.i+j) && isHex(rune(p.text[p.i+j])) {
 						j++
 					}
 					if j == 6 {
 						p.output.WriteString(p.text[p.i : p.i+6])
 						p.i += 6
-					} else if p.i+j >= len(p.text) {
+					} else if !p.has(p.i + j) {
 						// Truncated unicode - skip these characters and treat as end of string
 						// Jump to end to trigger missing quote repair
+						p.repair(RepairInvalidEscape, p.i, p.text[p.i:], "")
 						p.i = len(p.text)
 					} else {
-						return false
+						p.throwInvalidUnicodeCharacter()
 					}
+				} else if p.parsePythonEscape() || p.parseJSON5Escape() {
+					// Converted a Python or JSON5 escape like \x41
 				} else {
 					// Invalid escape - remove backslash
+					p.repair(RepairInvalidEscape, p.i, p.text[p.i:p.i+currentSize+nextSize], string(nextChar))
 					p.output.WriteRune(nextChar)
 					p.i += currentSize + nextSize
 				}
 			} else {
+				// Truncated escape at the end of the text - remove backslash
+				p.repair(RepairInvalidEscape, p.i, "\\", "")
 				p.i += currentSize
 			}
 		} else {
@@ -668,13 +911,13 @@ func (p *Parser) parseString(stopAtDelimiter bool, stopAtIndex int) bool {
 				p.i += currentSize
 			} else if isControlCharacter(currentR) {
 				// Control character
-				if escaped, ok := controlCharacters[currentR]; ok {
-					p.output.WriteString(escaped)
-				}
+				escaped := controlCharacters[currentR]
+				p.repair(RepairControlCharacter, p.i, p.text[p.i:p.i+currentSize], escaped)
+				p.output.WriteString(escaped)
 				p.i += currentSize
 			} else {
 				if !isValidStringCharacter(currentR) {
-					return false
+					p.throwInvalidCharacter(currentR)
 				}
 				p.output.WriteRune(currentR)
 				p.i += currentSize
@@ -687,33 +930,126 @@ func (p *Parser) parseString(stopAtDelimiter bool, stopAtIndex int) bool {
 	}
 }
 
+// parseTripleQuotedString parses a Python string delimited by three single
+// or three double quotes, which may span several lines and hold quotes of
+// both kinds.
+// It returns false when the text holds no closing triple quote, leaving the
+// quotes to parseString.
+func (p *Parser) parseTripleQuotedString() bool {
+	if !p.has(p.i + 2) {
+		return false
+	}
+	quote := p.text[p.i]
+	if quote != '\'' && quote != '"' || p.text[p.i+1] != quote || p.text[p.i+2] != quote {
+		return false
+	}
+	delimiter := p.text[p.i : p.i+3]
+
+	// Find the closing triple quote, skipping escaped characters. Quotes
+	// before it are part of the string, like in """say "hi"""".
+	end := p.i + 3
+	for {
+		if !p.has(end + 2) {
+			return false
+		}
+		if p.text[end:end+3] == delimiter {
+			break
+		}
+		if p.text[end] == '\\' {
+			end++
+		}
+		end++
+	}
+	for p.has(end+3) && p.text[end+3] == quote {
+		end++
+	}
+
+	start := p.i
+	p.repair(RepairQuotes, p.i, delimiter, "\"")
+	p.output.WriteRune('"')
+	p.i += 3
+	for p.i < end {
+		p.checkContext()
+		p.checkStringLength(start)
+		r, size := utf8.DecodeRuneInString(p.text[p.i:])
+		switch {
+		case r == '\\' && p.raw:
+			p.repair(RepairPythonString, p.i, "\\", `\\`)
+			p.output.WriteString(`\\`)
+			p.i++
+		case r == '\\':
+			next, nextSize := utf8.DecodeRuneInString(p.text[p.i+1:])
+			if _, ok := escapeCharacters[next]; ok {
+				p.output.WriteString(p.text[p.i : p.i+1+nextSize])
+				p.i += 1 + nextSize
+			} else if next == 'u' && p.i+6 <= end && isHexString(p.text[p.i+2:p.i+6]) {
+				p.output.WriteString(p.text[p.i : p.i+6])
+				p.i += 6
+			} else if p.i+1 < end && (p.parsePythonEscape() || p.parseJSON5Escape()) {
+				// Converted a Python or JSON5 escape like \x41
+			} else {
+				// Invalid escape like \' - remove the backslash and write the
+				// character after it as any other
+				p.repair(RepairInvalidEscape, p.i, "\\", "")
+				p.i++
+			}
+		case r == '"':
+			p.output.WriteString("\\\"")
+			p.i += size
+		case isControlCharacter(r):
+			escaped := controlCharacters[r]
+			p.repair(RepairControlCharacter, p.i, p.text[p.i:p.i+size], escaped)
+			p.output.WriteString(escaped)
+			p.i += size
+		default:
+			if !isValidStringCharacter(r) {
+				p.throwInvalidCharacter(r)
+			}
+			p.output.WriteRune(r)
+			p.i += size
+		}
+	}
+
+	p.repair(RepairQuotes, p.i, delimiter, "\"")
+	p.output.WriteRune('"')
+	p.i += 3
+	return true
+}
+
+// writtenKey returns the object key written to the output from position
+// keyStart on, which was parsed from input offset keyInput. When the key was
+// written as is, the returned string shares the memory of the input.
+func (p *Parser) writtenKey(keyStart, keyInput int) string {
+	written := bytes.TrimSpace(p.output.view(keyStart))
+	if input := p.text[keyInput-p.offset : p.i]; len(input) >= len(written) && input[:len(written)] == string(written) {
+		return input[:len(written)]
+	}
+	return string(written)
+}
+
 // parseConcatenatedString repairs concatenated strings like "hello" + "world"
 func (p *Parser) parseConcatenatedString() bool {
 	processed := false
 
 	p.parseWhitespaceAndSkipComments(true)
-	for p.i < len(p.text) && p.text[p.i] == '+' {
+	for p.has(p.i) && p.text[p.i] == '+' {
 		processed = true
+		plus := p.i
 		p.i++
 		p.parseWhitespaceAndSkipComments(true)
 
 		// Remove end quote of first string
-		output := p.output.String()
-		p.output.Reset()
-		p.output.WriteString(stripLastOccurrence(output, "\"", true))
+		end := p.output.stripLastOccurrence("\"", true)
+		p.record(Repair{Kind: RepairConcatenation, InputOffset: p.offset + plus, OutputOffset: end, Original: "+"})
 
 		start := p.output.Len()
 		parsedStr := p.parseString(false, -1)
 		if parsedStr {
 			// Remove start quote of second string
-			output := p.output.String()
-			p.output.Reset()
-			p.output.WriteString(removeAtIndex(output, start, 1))
+			p.output.removeAt(start, 1)
 		} else {
 			// Remove the + because it's not followed by a string
-			output := p.output.String()
-			p.output.Reset()
-			p.output.WriteString(insertBeforeLastWhitespace(output, "\""))
+			p.output.insertBeforeLastWhitespace("\"")
 		}
 	}
 
@@ -722,56 +1058,59 @@ func (p *Parser) parseConcatenatedString() bool {
 
 // parseNumber parses a JSON number
 func (p *Parser) parseNumber() bool {
+	if p.parseJSON5Number() {
+		return true
+	}
 	start := p.i
 
-	if p.i < len(p.text) && p.text[p.i] == '-' {
+	if p.has(p.i) && p.text[p.i] == '-' {
 		p.i++
 		if p.atEndOfNumber() {
 			p.repairNumberEndingWithNumericSymbol(start)
 			return true
 		}
-		if p.i >= len(p.text) || !isDigit(rune(p.text[p.i])) {
+		if !p.has(p.i) || !isDigit(rune(p.text[p.i])) {
 			p.i = start
 			return false
 		}
 	}
 
 	// Integer part
-	for p.i < len(p.text) && isDigit(rune(p.text[p.i])) {
+	for p.has(p.i) && isDigit(rune(p.text[p.i])) {
 		p.i++
 	}
 
 	// Decimal part
-	if p.i < len(p.text) && p.text[p.i] == '.' {
+	if p.has(p.i) && p.text[p.i] == '.' {
 		p.i++
 		if p.atEndOfNumber() {
 			p.repairNumberEndingWithNumericSymbol(start)
 			return true
 		}
-		if p.i >= len(p.text) || !isDigit(rune(p.text[p.i])) {
+		if !p.has(p.i) || !isDigit(rune(p.text[p.i])) {
 			p.i = start
 			return false
 		}
-		for p.i < len(p.text) && isDigit(rune(p.text[p.i])) {
+		for p.has(p.i) && isDigit(rune(p.text[p.i])) {
 			p.i++
 		}
 	}
 
 	// Exponent part
-	if p.i < len(p.text) && (p.text[p.i] == 'e' || p.text[p.i] == 'E') {
+	if p.has(p.i) && (p.text[p.i] == 'e' || p.text[p.i] == 'E') {
 		p.i++
-		if p.i < len(p.text) && (p.text[p.i] == '-' || p.text[p.i] == '+') {
+		if p.has(p.i) && (p.text[p.i] == '-' || p.text[p.i] == '+') {
 			p.i++
 		}
 		if p.atEndOfNumber() {
 			p.repairNumberEndingWithNumericSymbol(start)
 			return true
 		}
-		if p.i >= len(p.text) || !isDigit(rune(p.text[p.i])) {
+		if !p.has(p.i) || !isDigit(rune(p.text[p.i])) {
 			p.i = start
 			return false
 		}
-		for p.i < len(p.text) && isDigit(rune(p.text[p.i])) {
+		for p.has(p.i) && isDigit(rune(p.text[p.i])) {
 			p.i++
 		}
 	}
@@ -786,6 +1125,7 @@ func (p *Parser) parseNumber() bool {
 		// Check for leading zeros
 		if len(num) > 1 && num[0] == '0' && num[1] >= '0' && num[1] <= '9' {
 			// Has invalid leading zero - quote it
+			p.repair(RepairNumber, start, num, "\""+num+"\"")
 			p.output.WriteString("\"")
 			p.output.WriteString(num)
 			p.output.WriteString("\"")
@@ -811,7 +1151,10 @@ func (p *Parser) parseKeywords() bool {
 // parseKeyword parses a specific keyword
 func (p *Parser) parseKeyword(name, value string) bool {
 	end := p.i + len(name)
-	if end <= len(p.text) && p.text[p.i:end] == name {
+	if p.has(end-1) && p.text[p.i:end] == name {
+		if name != value {
+			p.repair(RepairPythonConstant, p.i, name, value)
+		}
 		p.output.WriteString(value)
 		p.i = end
 		return true
@@ -823,10 +1166,10 @@ func (p *Parser) parseKeyword(name, value string) bool {
 func (p *Parser) parseUnquotedString(isKey bool) bool {
 	start := p.i
 
-	if p.i < len(p.text) {
+	if p.has(p.i) {
 		r, _ := getCharAt(p.text, p.i)
 		if isFunctionNameCharStart(r) {
-			for p.i < len(p.text) {
+			for p.has(p.i) {
 				r, size := utf8.DecodeRuneInString(p.text[p.i:])
 				if isFunctionNameChar(r) {
 					p.i += size
@@ -837,43 +1180,45 @@ func (p *Parser) parseUnquotedString(isKey bool) bool {
 
 			// Check for function call
 			j := p.i
-			for j < len(p.text) && isWhitespace(p.text, j) {
+			for p.has(j) && isWhitespace(p.text, j) {
 				j++
 			}
 
-			if j < len(p.text) && p.text[j] == '(' {
+			if p.has(j) && p.text[j] == '(' {
 				// Function call like NumberLong(2) or Timestamp(1234, 1) or callback({})
+				m := p.mark()
+				p.repair(RepairFunctionCall, start, p.text[start:j+1], "")
 				p.i = j + 1
 
-				// Parse the first value
-				p.parseValue()
-
-				// Skip any additional arguments (e.g., Timestamp(1234, 1))
-				for p.i < len(p.text) && p.text[p.i] == ',' {
-					p.i++ // skip comma
-					// Save output BEFORE parsing whitespace to avoid trailing spaces
-					savedOutput := p.output.String()
-					p.parseWhitespaceAndSkipComments(true)
-					// Skip this value - we only keep the first one
-					p.parseValue()
-					// Restore output to discard this value and any whitespace before it
-					p.output.Reset()
-					p.output.WriteString(savedOutput)
+				// Parse the first value. A call in place of a key cannot be
+				// resumed from a checkpoint, so hold those off.
+				if isKey {
+					p.hold++
 				}
-
-				if p.i < len(p.text) && p.text[p.i] == ')' {
-					p.i++
-					if p.i < len(p.text) && p.text[p.i] == ';' {
-						p.i++
-					}
+				p.checkDepth()
+				p.pushFrame(frameFunctionCall)
+				processed := p.parseValue()
+				p.popFrame()
+				if processed {
+					p.parseFunctionCallEnd()
 				}
-				return true
+				if isKey {
+					p.hold--
+				}
+				if processed {
+					return true
+				}
+
+				// Without a first argument, like in foo(, 1), the name and
+				// the parenthesis are text
+				p.rollback(m)
 			}
 		}
 	}
 
 	// Parse unquoted string
-	for p.i < len(p.text) {
+	for p.has(p.i) {
+		p.checkStringLength(start)
 		r, size := utf8.DecodeRuneInString(p.text[p.i:])
 		if isUnquotedStringDelimiter(r) || isQuote(r) || (isKey && r == ':') {
 			break
@@ -882,8 +1227,8 @@ func (p *Parser) parseUnquotedString(isKey bool) bool {
 	}
 
 	// Check for URL
-	if p.i > 0 && p.text[p.i-1] == ':' && matchesUrlStart(p.text, start, p.i+2) {
-		for p.i < len(p.text) {
+	if p.i > 0 && p.text[p.i-1] == ':' && p.matchesUrlStart(start, p.i+2) {
+		for p.has(p.i) {
 			r, size := utf8.DecodeRuneInString(p.text[p.i:])
 			if matchesUrlChar(r) {
 				p.i += size
@@ -900,42 +1245,86 @@ func (p *Parser) parseUnquotedString(isKey bool) bool {
 		}
 
 		symbol := p.text[start:p.i]
+		fix := Repair{Kind: RepairUnquotedString, OutputOffset: p.output.next()}
 		if symbol == "undefined" {
-			p.output.WriteString("null")
+			fix.Kind = RepairUndefined
+			fix.Replacement = "null"
+			p.output.WriteString(fix.Replacement)
 		} else {
 			// Quote the string
-			jsonStr, _ := json.Marshal(symbol)
-			p.output.WriteString(string(jsonStr))
+			if isKey && p.dialect == DialectJSON5 {
+				symbol = unescapeIdentifier(symbol)
+			}
+			p.writeQuoted(symbol)
+			if p.recording(fix.Kind) {
+				fix.Replacement = p.output.since(fix.OutputOffset)
+			}
 		}
 
 		// Skip end quote if present
-		if p.i < len(p.text) && p.text[p.i] == '"' {
+		if p.has(p.i) && p.text[p.i] == '"' {
 			p.i++
 		}
 
+		fix.InputOffset = p.offset + start
+		fix.Original = p.text[start:p.i]
+		p.record(fix)
+
 		return true
 	}
 
 	return false
 }
 
+// parseFunctionCallEnd skips the remaining arguments and the closing
+// parenthesis of a function call whose first argument has been parsed
+func (p *Parser) parseFunctionCallEnd() {
+	start := p.i
+
+	// Skip any additional arguments (e.g., Timestamp(1234, 1))
+	for p.has(p.i) && p.text[p.i] == ',' {
+		p.i++ // skip comma
+		// Mark output BEFORE parsing whitespace to avoid trailing spaces
+		m := p.mark()
+		p.hold++
+		p.parseWhitespaceAndSkipComments(true)
+		// Skip this value - we only keep the first one
+		p.parseValue()
+		// Discard this value and any whitespace before it
+		p.discard(m)
+		p.hold--
+	}
+
+	if p.has(p.i) && p.text[p.i] == ')' {
+		p.i++
+		if p.has(p.i) && p.text[p.i] == ';' {
+			p.i++
+		}
+	}
+
+	if p.i > start {
+		p.repair(RepairFunctionCall, start, p.text[start:p.i], "")
+	}
+}
+
 // parseRegex parses a regex literal and converts it to a string
 func (p *Parser) parseRegex() bool {
-	if p.i >= len(p.text) || p.text[p.i] != '/' {
+	if !p.has(p.i) || p.text[p.i] != '/' {
 		return false
 	}
 
 	start := p.i
 	p.i++
 
-	for p.i < len(p.text) && (p.text[p.i] != '/' || (p.i > 0 && p.text[p.i-1] == '\\')) {
+	for p.has(p.i) && (p.text[p.i] != '/' || (p.i > 0 && p.text[p.i-1] == '\\')) {
 		p.i++
 	}
 
-	if p.i < len(p.text) {
+	if p.has(p.i) {
 		p.i++ // Skip closing /
 	}
 
+	p.repair(RepairRegex, start, p.text[start:p.i], "\""+p.text[start:p.i]+"\"")
 	p.output.WriteString("\"")
 	p.output.WriteString(p.text[start:p.i])
 	p.output.WriteString("\"")
@@ -944,6 +1333,192 @@ func (p *Parser) parseRegex() bool {
 
 // Helper methods
 
+// repair records that original at input position pos was replaced by
+// replacement, which is about to be written at the end of the output
+func (p *Parser) repair(kind RepairKind, pos int, original, replacement string) {
+	n := p.output.Len()
+	if replacement != "" || p.format == FormatPreserve {
+		// The pending whitespace is written before the replacement, and
+		// whitespace of the input is kept before a removal
+		n = p.output.next()
+	}
+	p.record(Repair{
+		Kind:         kind,
+		InputOffset:  p.offset + pos,
+		OutputOffset: n,
+		Original:     original,
+		Replacement:  replacement,
+	})
+}
+
+// insert inserts text before the trailing whitespace of the output as a
+// repair of the given kind at the current input position
+func (p *Parser) insert(kind RepairKind, text string) {
+	n := p.output.insertBeforeLastWhitespace(text)
+	if p.format != FormatPreserve {
+		// The whitespace of the input is left out, so whitespace after the
+		// inserted text was written in a string, like the whitespace before
+		// a missing end quote
+		p.output.leaveOut(p.output.since(n + len(text)))
+		p.output.truncate(n + len(text))
+	}
+	p.record(Repair{Kind: kind, InputOffset: p.offset + p.i, OutputOffset: n, Replacement: text})
+}
+
+// record records r when reporting or when its kind is disabled
+func (p *Parser) record(r Repair) {
+	if p.recording(r.Kind) {
+		p.repairs = append(p.repairs, r)
+	}
+}
+
+// recording reports whether repairs of the given kind are recorded
+func (p *Parser) recording(kind RepairKind) bool {
+	return p.reporting || !p.allowed(kind)
+}
+
+// writeQuoted writes text as a JSON string, escaped like json.Marshal does
+func (p *Parser) writeQuoted(text string) {
+	for j := 0; j < len(text); j++ {
+		if c := text[j]; c < 0x20 || c >= utf8.RuneSelf || c == '"' || c == '\\' || c == '<' || c == '>' || c == '&' {
+			quoted, _ := json.Marshal(text)
+			p.output.WriteString(string(quoted))
+			return
+		}
+	}
+	p.output.WriteRune('"')
+	p.output.WriteString(text)
+	p.output.WriteRune('"')
+}
+
+// checkRepairs aborts parsing when a disabled repair has been applied. It
+// is called where the repairs made so far can no longer be rolled back.
+func (p *Parser) checkRepairs() {
+	for _, r := range p.repairs[p.checked:] {
+		if !p.allowed(r.Kind) {
+			p.fail(p.newError(CodeRepairDisabled, "Repair disabled: "+r.Kind.String(), r.InputOffset))
+		}
+	}
+	p.checked = len(p.repairs)
+}
+
+// stripTrailingComma removes the last comma from the output. missingComma
+// is the input offset where the comma being removed was inserted by a
+// missing comma repair, or -1 when it was not.
+func (p *Parser) stripTrailingComma(missingComma int) {
+	n := p.output.stripLastOccurrence(",", false)
+	if n == -1 {
+		return
+	}
+	if missingComma == -1 {
+		p.record(Repair{Kind: RepairTrailingComma, InputOffset: p.comma, OutputOffset: n, Original: ","})
+		return
+	}
+	// Take back the missing comma repair
+	for j := len(p.repairs) - 1; j >= p.checked; j-- {
+		r := p.repairs[j]
+		if r.InputOffset == missingComma && r.Original == "" && r.Replacement == "," {
+			p.repairs = slices.Delete(p.repairs, j, j+1)
+			break
+		}
+	}
+}
+
+// mark returns the current state of the parser for a later rollback
+func (p *Parser) mark() marker {
+	return marker{
+		i:       p.i,
+		output:  p.output.Len(),
+		repairs: len(p.repairs),
+		keys:    len(p.keys),
+		pending: p.output.pending,
+		open:    p.output.open,
+	}
+}
+
+// discard drops the output and repairs made since m, keeping the position
+func (p *Parser) discard(m marker) {
+	p.output.truncate(m.output)
+	p.output.pending, p.output.open = m.pending, m.open
+	p.repairs = p.repairs[:m.repairs]
+	p.keys = p.keys[:m.keys]
+}
+
+// rollback restores the state of the parser to m
+func (p *Parser) rollback(m marker) {
+	p.discard(m)
+	p.i = m.i
+}
+
+// fail aborts parsing with err
+func (p *Parser) fail(err error) {
+	panic(bailout{err: err})
+}
+
+// recoverBailout recovers from a call to fail, storing its error in err
+func (p *Parser) recoverBailout(err *error) {
+	if r := recover(); r != nil {
+		b, ok := r.(bailout)
+		if !ok {
+			panic(r)
+		}
+		*err = b.err
+	}
+}
+
+// layout sets the whitespace to write before the next member of the
+// innermost container. open tells whether no member has been written yet.
+func (p *Parser) layout(open bool) {
+	if p.format == FormatIndent {
+		p.output.pending = "\n" + strings.Repeat(p.indent, p.depth())
+	}
+	p.output.open = open
+}
+
+// closeBracket writes the end bracket of the innermost container, as a
+// repair when it is missing from the input
+func (p *Parser) closeBracket(bracket string, missing bool) {
+	if p.format == FormatPreserve {
+		if missing {
+			p.insert(RepairMissingBracket, bracket)
+		} else {
+			p.output.WriteString(bracket)
+		}
+		return
+	}
+
+	p.output.pending = ""
+	if p.format == FormatIndent && !p.output.open {
+		p.output.pending = "\n" + strings.Repeat(p.indent, p.depth()-1)
+	}
+	if missing {
+		p.repair(RepairMissingBracket, p.i, "", bracket)
+	}
+	p.output.WriteString(bracket)
+}
+
+// depth returns the number of objects and arrays enclosing the current
+// position
+func (p *Parser) depth() int {
+	depth := 0
+	for _, f := range p.stack {
+		if f.kind != frameFunctionCall {
+			depth++
+		}
+	}
+	return depth
+}
+
+// pushFrame records that the parser entered a container
+func (p *Parser) pushFrame(kind frameKind) {
+	p.stack = append(p.stack, frame{kind: kind, initial: true})
+}
+
+// popFrame records that the parser left the innermost container
+func (p *Parser) popFrame() {
+	p.stack = p.stack[:len(p.stack)-1]
+}
+
 func (p *Parser) prevNonWhitespaceIndex(start int) int {
 	prev := start
 	for prev > 0 && isWhitespace(p.text, prev) {
@@ -952,12 +1527,31 @@ func (p *Parser) prevNonWhitespaceIndex(start int) int {
 	return prev
 }
 
+// matchesUrlStart checks if the input between start and end is a URL scheme
+func (p *Parser) matchesUrlStart(start, end int) bool {
+	p.has(end - 1)
+	return matchesUrlStart(p.text, start, end)
+}
+
 func (p *Parser) atEndOfNumber() bool {
-	return p.i >= len(p.text) || isDelimiter(rune(p.text[p.i])) || isWhitespace(p.text, p.i)
+	return !p.has(p.i) || isDelimiter(rune(p.text[p.i])) || isWhitespace(p.text, p.i)
+}
+
+// nonFiniteValue returns how the number NaN, Infinity or -Infinity at
+// position pos is written, according to the NonFinite policy
+func (p *Parser) nonFiniteValue(number string, pos int) string {
+	switch p.nonFinite {
+	case NonFiniteString:
+		return `"` + number + `"`
+	case NonFiniteError:
+		p.fail(p.newError(CodeNonFiniteNumber, "Non-finite number "+number, p.offset+pos))
+	}
+	return "null"
 }
 
 func (p *Parser) repairNumberEndingWithNumericSymbol(start int) {
 	p.output.WriteString(p.text[start:p.i])
+	p.repair(RepairNumber, p.i, "", "0")
 	p.output.WriteString("0")
 }
 
@@ -966,7 +1560,7 @@ func (p *Parser) repairNumberEndingWithNumericSymbol(start int) {
 // rather than a valid end quote.
 // Returns true if the position is suspicious, false if it looks like a valid end quote.
 func (p *Parser) isUnescapedQuoteSuspicious(afterQuoteIndex int) bool {
-	if afterQuoteIndex >= len(p.text) {
+	if !p.has(afterQuoteIndex) {
 		return false // End of text - not suspicious
 	}
 
@@ -981,6 +1575,10 @@ func (p *Parser) isUnescapedQuoteSuspicious(afterQuoteIndex int) bool {
 	if charAfterQuote == '}' || charAfterQuote == ']' || charAfterQuote == ':' {
 		return false
 	}
+	if charAfterQuote == ')' && p.dialect == DialectPython {
+		// End of a tuple
+		return false
+	}
 
 	// String concatenation operator - not suspicious
 	if charAfterQuote == '+' {
@@ -991,10 +1589,10 @@ func (p *Parser) isUnescapedQuoteSuspicious(afterQuoteIndex int) bool {
 	if charAfterQuote == ',' {
 		j := afterQuoteIndex + 1
 		// Skip whitespace after comma
-		for j < len(p.text) && isWhitespace(p.text, j) {
+		for p.has(j) && isWhitespace(p.text, j) {
 			j++
 		}
-		if j >= len(p.text) {
+		if !p.has(j) {
 			return false
 		}
 		afterComma, _ := getCharAt(p.text, j)
@@ -1005,17 +1603,20 @@ func (p *Parser) isUnescapedQuoteSuspicious(afterQuoteIndex int) bool {
 			return false
 		}
 		// Check for comments (/* or //)
-		if afterComma == '/' && j+1 < len(p.text) {
+		if afterComma == '/' && p.has(j+1) {
 			nextChar := p.text[j+1]
 			if nextChar == '*' || nextChar == '/' {
 				return false
 			}
 		}
+		if p.dialect == DialectPython && (afterComma == '(' || p.atPythonValue(j)) {
+			return false
+		}
 		// For identifiers, check if it's likely an unquoted key or string content
 		if isFunctionNameCharStart(afterComma) {
 			// Skip the identifier
 			k := j
-			for k < len(p.text) {
+			for p.has(k) {
 				r, size := utf8.DecodeRuneInString(p.text[k:])
 				if isFunctionNameChar(r) {
 					k += size
@@ -1024,23 +1625,28 @@ func (p *Parser) isUnescapedQuoteSuspicious(afterQuoteIndex int) bool {
 				}
 			}
 			// Skip whitespace after the identifier
-			for k < len(p.text) && isWhitespace(p.text, k) {
+			for p.has(k) && isWhitespace(p.text, k) {
 				k++
 			}
 			// Check what comes after the identifier
-			if k < len(p.text) {
+			if p.has(k) {
 				afterIdent, _ := getCharAt(p.text, k)
 				// If it's followed by ':', it's an unquoted key - not suspicious
 				if afterIdent == ':' {
 					return false
 				}
+				// If it's followed by a delimiter, it's a value like true or
+				// an unquoted string - not suspicious
+				if afterIdent == ',' || afterIdent == '}' || afterIdent == ']' {
+					return false
+				}
 				// If it's a quote followed by ':', it's an unquoted key with quote - not suspicious
 				if isQuote(afterIdent) {
 					m := k + 1
-					for m < len(p.text) && isWhitespace(p.text, m) {
+					for p.has(m) && isWhitespace(p.text, m) {
 						m++
 					}
-					if m < len(p.text) && p.text[m] == ':' {
+					if p.has(m) && p.text[m] == ':' {
 						return false
 					}
 				}
@@ -1064,20 +1670,21 @@ func (p *Parser) findNextValidEndQuote(startIndex int) int {
 	j := startIndex
 
 	// Search for the next quote that could be a valid end quote
-	for j < len(p.text) {
+	for p.has(j) {
+		p.checkContext()
 		r, _ := getCharAt(p.text, j)
 		if isQuote(r) {
 			// Found a quote, check if it's followed by a valid JSON value delimiter
 			k := j + 1
 
 			// Skip whitespace after the quote
-			for k < len(p.text) && isWhitespace(p.text, k) {
+			for p.has(k) && isWhitespace(p.text, k) {
 				k++
 			}
 
 			// Check if what follows is a valid JSON structure continuation for a value
 			// Note: we exclude ':' because that would indicate this is a key quote, not a value quote
-			if k >= len(p.text) {
+			if !p.has(k) {
 				return j // End of text - this is a valid end quote
 			}
 			afterQuote, _ := getCharAt(p.text, k)
@@ -1095,12 +1702,24 @@ func (p *Parser) findNextValidEndQuote(startIndex int) int {
 
 func (p *Parser) throwUnexpectedCharacter() error {
 	char := ""
-	if p.i < len(p.text) {
+	if p.has(p.i) {
 		char = strconv.QuoteRune(rune(p.text[p.i]))
 	}
-	return NewJSONRepairError("Unexpected character "+char, p.i)
+	return p.newError(CodeUnexpectedCharacter, "Unexpected character "+char, p.offset+p.i)
 }
 
 func (p *Parser) throwUnexpectedEnd() error {
-	return NewJSONRepairError("Unexpected end of json string", len(p.text))
+	return p.newError(CodeUnexpectedEnd, "Unexpected end of json string", p.inputLen())
+}
+
+func (p *Parser) throwInvalidUnicodeCharacter() {
+	end := p.i + 2
+	for end < p.i+6 && p.has(end) {
+		end++
+	}
+	p.fail(p.newError(CodeInvalidUnicode, "Invalid unicode character "+strconv.Quote(p.text[p.i:end]), p.offset+p.i))
+}
+
+func (p *Parser) throwInvalidCharacter(char rune) {
+	p.fail(p.newError(CodeInvalidCharacter, "Invalid character "+strconv.QuoteRune(char), p.offset+p.i))
 }
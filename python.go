@@ -0,0 +1,526 @@
+package jsonrepair
+
+import (
+	"fmt"
+	"regexp"
+	"slices"
+	"strconv"
+	"strings"
+	"unicode/utf8"
+)
+
+// timedeltaFieldRegex matches a field of the repr() of a Python timedelta,
+// like days=-1 in datetime.timedelta(days=-1, seconds=68400)
+var timedeltaFieldRegex = regexp.MustCompile(`(days|seconds|minutes|hours)=(-?\d+)`)
+
+// parseTuple parses a Python tuple like (1, 2) into an array
+func (p *Parser) parseTuple() bool {
+	if p.dialect != DialectPython || !p.has(p.i) || p.text[p.i] != '(' {
+		return false
+	}
+	return p.parseCollection(')')
+}
+
+// atSet reports whether the curly bracket at the current position opens a
+// Python set like {1, 2} rather than a dict, which is the case when its
+// first item is not followed by a colon
+func (p *Parser) atSet() bool {
+	m := p.mark()
+	p.hold++
+	p.i++
+	p.parseWhitespaceAndSkipComments(true)
+	set := false
+	if p.has(p.i) && p.text[p.i] != '}' && (p.parseString(false, -1) || p.parseTuple() || p.parseUnquotedString(true)) {
+		p.parseWhitespaceAndSkipComments(true)
+		set = p.has(p.i) && (p.text[p.i] == ',' || p.text[p.i] == '}')
+	}
+	p.hold--
+	p.rollback(m)
+	return set
+}
+
+// atPythonValue reports whether the identifier at position j starts a
+// Python value rather than text, like b'x' or Decimal('1.2')
+func (p *Parser) atPythonValue(j int) bool {
+	start := j
+	for p.has(j) && (isFunctionNameChar(rune(p.text[j])) || p.text[j] == '.') {
+		j++
+	}
+	if j == start || !p.has(j) {
+		return false
+	}
+	if p.text[j] == '\'' || p.text[j] == '"' {
+		return j-start <= 2 && strings.Trim(p.text[start:j], "bBrRuU") == ""
+	}
+	for p.has(j) && isWhitespace(p.text, j) {
+		j++
+	}
+	return p.has(j) && p.text[j] == '('
+}
+
+// parseCollection parses the Python tuple or set opened at the current
+// position into an array. closing is its end bracket. A collection that
+// ends before a character that is no item is left to the other parsers, as
+// its bracket is then rather text like in (1, (, 2).
+func (p *Parser) parseCollection(closing byte) bool {
+	p.checkDepth()
+	m := p.mark()
+	p.hold++
+	p.repair(RepairPythonValue, p.i, p.text[p.i:p.i+1], "[")
+	p.output.WriteRune('[')
+	p.i++
+	p.parseWhitespaceAndSkipComments(true)
+
+	p.pushFrame(frameArray)
+	p.stack[len(p.stack)-1].closing = closing
+	p.layout(true)
+	closed := p.parseArrayMembers(true)
+	p.popFrame()
+	p.hold--
+	if !closed {
+		p.rollback(m)
+	}
+	return closed
+}
+
+// parsePythonStringPrefix skips the prefix of a Python string like b'x' or
+// r'x', noting whether backslashes are literal in the string
+func (p *Parser) parsePythonStringPrefix() bool {
+	if p.dialect != DialectPython {
+		return false
+	}
+	n := 0
+	for n < 2 && p.has(p.i+n) && strings.IndexByte("bBrRuU", p.text[p.i+n]) != -1 {
+		n++
+	}
+	if n == 0 || !p.has(p.i+n) || p.text[p.i+n] != '\'' && p.text[p.i+n] != '"' {
+		return false
+	}
+	prefix := p.text[p.i : p.i+n]
+	p.repair(RepairPythonString, p.i, prefix, "")
+	p.raw = strings.ContainsAny(prefix, "rR")
+	p.i += n
+	return true
+}
+
+// parsePythonEscape converts the Python escape sequence at the current
+// position that JSON does not have, like \x41, \N{BULLET} or a backslash
+// continuing the string on the next line. It returns false when it is no
+// Python escape either.
+func (p *Parser) parsePythonEscape() bool {
+	if p.dialect != DialectPython || !p.has(p.i+1) {
+		return false
+	}
+	start := p.i
+	var r rune
+	switch c := p.text[p.i+1]; {
+	case c == '\n':
+		p.repair(RepairPythonString, start, p.text[start:start+2], "")
+		p.i += 2
+		return true
+	case c == '\'':
+		r = '\''
+	case c == 'a':
+		r = '\a'
+	case c == 'v':
+		r = '\v'
+	case c == 'x' || c == 'U':
+		digits := 2
+		if c == 'U' {
+			digits = 8
+		}
+		if !p.has(p.i+1+digits) || !isHexString(p.text[p.i+2:p.i+2+digits]) {
+			return false
+		}
+		code, _ := strconv.ParseUint(p.text[p.i+2:p.i+2+digits], 16, 32)
+		if !utf8.ValidRune(rune(code)) {
+			return false
+		}
+		r = rune(code)
+		p.i += digits
+	case c >= '0' && c <= '7':
+		// Up to three octal digits
+		n := 1
+		for n < 3 && p.has(p.i+1+n) && p.text[p.i+1+n] >= '0' && p.text[p.i+1+n] <= '7' {
+			n++
+		}
+		code, _ := strconv.ParseUint(p.text[p.i+1:p.i+1+n], 8, 32)
+		r = rune(code)
+		p.i += n - 1
+	case c == 'N':
+		end := -1
+		if p.has(p.i+2) && p.text[p.i+2] == '{' {
+			for j := p.i + 3; p.has(j) && j < p.i+3+maxUnicodeNameLength; j++ {
+				if p.text[j] == '}' {
+					end = j
+					break
+				}
+			}
+		}
+		if end == -1 {
+			return false
+		}
+		named, ok := unicodeName(p.text[p.i+3 : end])
+		if !ok {
+			// An unknown name is kept as it is, with its backslash escaped
+			p.i = end + 1
+			p.repair(RepairPythonString, start, p.text[start:p.i], `\`+p.text[start:p.i])
+			p.output.WriteString(`\` + p.text[start:p.i])
+			return true
+		}
+		r = named
+		p.i = end - 1
+	default:
+		return false
+	}
+	p.i += 2
+
+	escaped := escapeRune(r)
+	p.repair(RepairPythonString, start, p.text[start:p.i], escaped)
+	p.output.WriteString(escaped)
+	return true
+}
+
+// escapeRune returns r as it is written in a JSON string
+func escapeRune(r rune) string {
+	switch {
+	case r == '"' || r == '\\':
+		return `\` + string(r)
+	case controlCharacters[r] != "":
+		return controlCharacters[r]
+	case r < 0x20:
+		return fmt.Sprintf(`\u%04x`, r)
+	}
+	return string(r)
+}
+
+// parsePythonValue parses a Python value that JSON does not have, like nan,
+// float('inf'), Decimal('1.2') or datetime.date(2024, 1, 2), or a call
+// creating an empty collection like set()
+func (p *Parser) parsePythonValue() bool {
+	if p.dialect != DialectPython || !p.has(p.i) {
+		return false
+	}
+	start := p.i
+	j := p.i
+	if p.text[j] == '-' {
+		j++
+	}
+	nameStart := j
+	for p.has(j) && (isFunctionNameChar(rune(p.text[j])) || p.text[j] == '.') {
+		j++
+	}
+	name := p.text[nameStart:j]
+
+	value := ""
+	k := j
+	for p.has(k) && isWhitespace(p.text, k) {
+		k++
+	}
+	switch {
+	case name == "inf":
+		value = p.text[start:nameStart] + "Infinity"
+	case name == "nan" && nameStart == start:
+		value = "NaN"
+	case nameStart == start && name != "" && p.has(k) && p.text[k] == '(':
+		args, end, ok := p.pythonArguments(k + 1)
+		if !ok {
+			return false
+		}
+		if value, ok = pythonCall(name, args); !ok {
+			return false
+		}
+		j = end
+	default:
+		return false
+	}
+	switch value {
+	case "NaN", "Infinity", "-Infinity":
+		value = p.nonFiniteValue(value, start)
+	}
+
+	p.repair(RepairPythonValue, start, p.text[start:j], value)
+	p.output.WriteString(value)
+	p.i = j
+	return true
+}
+
+// pythonArguments splits the arguments of a call, from position j after
+// the opening parenthesis up to the closing one. It returns the position
+// after the closing parenthesis, and false when the call is not closed.
+func (p *Parser) pythonArguments(j int) (args []string, end int, ok bool) {
+	start := j
+	depth := 0
+	for p.has(j) {
+		switch c := p.text[j]; c {
+		case '\'', '"':
+			for j++; p.has(j) && p.text[j] != c; j++ {
+				if p.text[j] == '\\' {
+					j++
+				}
+			}
+		case '(', '[', '{':
+			depth++
+		case ']', '}':
+			depth--
+		case ')':
+			if depth == 0 {
+				if arg := strings.TrimSpace(p.text[start:j]); arg != "" || len(args) > 0 {
+					args = append(args, arg)
+				}
+				return args, j + 1, true
+			}
+			depth--
+		case ',':
+			if depth == 0 {
+				args = append(args, strings.TrimSpace(p.text[start:j]))
+				start = j + 1
+			}
+		}
+		j++
+	}
+	return nil, 0, false
+}
+
+// pythonCall returns the JSON value of a call of a Python constructor with
+// the given arguments, and false when it has none. Non-finite numbers are
+// returned as NaN, Infinity and -Infinity, to be written by the NonFinite
+// policy.
+func pythonCall(name string, args []string) (string, bool) {
+	switch name {
+	case "float", "Decimal", "decimal.Decimal":
+		if len(args) != 1 {
+			return "", false
+		}
+		number := args[0]
+		if unquoted, ok := unquotePython(number); ok {
+			number = strings.TrimSpace(unquoted)
+		}
+		switch strings.TrimLeft(strings.ToLower(number), "+-") {
+		case "nan", "snan":
+			return "NaN", true
+		case "inf", "infinity":
+			if strings.HasPrefix(number, "-") {
+				return "-Infinity", true
+			}
+			return "Infinity", true
+		}
+		number = strings.TrimPrefix(number, "+")
+		v := validator{text: number}
+		return number, v.number() && v.i == len(number)
+	case "set", "frozenset", "tuple", "list":
+		return "[]", len(args) == 0
+	case "dict":
+		return "{}", len(args) == 0
+	case "datetime.datetime", "datetime", "datetime.date", "date", "datetime.time", "time":
+		return pythonDateTime(name[strings.LastIndexByte(name, '.')+1:], args)
+	}
+	return "", false
+}
+
+// pythonDateTimeFields lists the arguments of the Python datetime
+// constructor in order, those of date and time being a part of them
+var pythonDateTimeFields = []string{"year", "month", "day", "hour", "minute", "second", "microsecond"}
+
+// pythonDateTime returns the arguments of a Python datetime, date or time
+// constructor as an ISO 8601 string. A time zone is written when it is
+// UTC or a fixed offset, and left out otherwise.
+func pythonDateTime(kind string, args []string) (string, bool) {
+	fields := pythonDateTimeFields
+	switch kind {
+	case "date":
+		fields = fields[:3]
+	case "time":
+		fields = fields[3:]
+	}
+
+	values := map[string]int{}
+	zone := ""
+	for j, arg := range args {
+		key, value, keyword := strings.Cut(arg, "=")
+		if keyword {
+			key, value = strings.TrimSpace(key), strings.TrimSpace(value)
+		} else {
+			// The time zone follows the fields
+			key, value = "tzinfo", arg
+			if j < len(fields) {
+				key = fields[j]
+			}
+		}
+		switch {
+		case key == "tzinfo" && kind != "date":
+			zone = pythonTimeZone(value)
+		case key == "fold":
+		case slices.Contains(fields, key):
+			n, err := strconv.Atoi(value)
+			if err != nil {
+				return "", false
+			}
+			values[key] = n
+		default:
+			return "", false
+		}
+	}
+
+	date := fmt.Sprintf("%04d-%02d-%02d", values["year"], values["month"], values["day"])
+	clock := fmt.Sprintf("%02d:%02d:%02d", values["hour"], values["minute"], values["second"])
+	if us := values["microsecond"]; us != 0 {
+		clock += fmt.Sprintf(".%06d", us)
+	}
+	switch kind {
+	case "date":
+		return `"` + date + `"`, len(values) == 3
+	case "time":
+		return `"` + clock + zone + `"`, true
+	}
+	_, hasYear := values["year"]
+	return `"` + date + "T" + clock + zone + `"`, hasYear && values["month"] != 0 && values["day"] != 0
+}
+
+// pythonTimeZone returns the ISO 8601 offset of the repr() of a Python time
+// zone, like Z for datetime.timezone.utc or +02:00 for
+// datetime.timezone(datetime.timedelta(seconds=7200)), and an empty string
+// for other zones
+func pythonTimeZone(zone string) string {
+	if strings.Contains(strings.ToLower(zone), "utc") {
+		return "Z"
+	}
+	if !strings.Contains(zone, "timedelta(") {
+		return ""
+	}
+	seconds := 0
+	for _, field := range timedeltaFieldRegex.FindAllStringSubmatch(zone, -1) {
+		n, _ := strconv.Atoi(field[2])
+		switch field[1] {
+		case "days":
+			seconds += n * 86400
+		case "hours":
+			seconds += n * 3600
+		case "minutes":
+			seconds += n * 60
+		default:
+			seconds += n
+		}
+	}
+	if seconds == 0 {
+		return "Z"
+	}
+	sign := "+"
+	if seconds < 0 {
+		sign, seconds = "-", -seconds
+	}
+	return fmt.Sprintf("%s%02d:%02d", sign, seconds/3600, seconds/60%60)
+}
+
+// unquotePython returns the content of a simple Python string literal
+// without escapes, like '1.2'
+func unquotePython(text string) (string, bool) {
+	if len(text) < 2 || text[0] != text[len(text)-1] || text[0] != '\'' && text[0] != '"' || strings.ContainsRune(text, '\\') {
+		return "", false
+	}
+	return text[1 : len(text)-1], true
+}
+
+// maxUnicodeNameLength is the length of the longest name looked up by
+// unicodeName
+const maxUnicodeNameLength = 64
+
+// unicodeNames maps the names of common characters that are not letters,
+// in upper case
+var unicodeNames = map[string]rune{
+	"SPACE":                       ' ',
+	"NO-BREAK SPACE":              '\u00a0',
+	"ZERO WIDTH SPACE":            '\u200b',
+	"ZERO WIDTH NON-JOINER":       '\u200c',
+	"ZERO WIDTH JOINER":           '\u200d',
+	"ZERO WIDTH NO-BREAK SPACE":   '\ufeff',
+	"EN DASH":                     '–',
+	"EM DASH":                     '—',
+	"HORIZONTAL ELLIPSIS":         '…',
+	"BULLET":                      '•',
+	"LEFT SINGLE QUOTATION MARK":  '‘',
+	"RIGHT SINGLE QUOTATION MARK": '’',
+	"LEFT DOUBLE QUOTATION MARK":  '“',
+	"RIGHT DOUBLE QUOTATION MARK": '”',
+	"DEGREE SIGN":                 '°',
+	"COPYRIGHT SIGN":              '©',
+	"REGISTERED SIGN":             '®',
+	"TRADE MARK SIGN":             '™',
+	"SECTION SIGN":                '§',
+	"PILCROW SIGN":                '¶',
+	"MICRO SIGN":                  'µ',
+	"CENT SIGN":                   '¢',
+	"POUND SIGN":                  '£',
+	"YEN SIGN":                    '¥',
+	"EURO SIGN":                   '€',
+	"PLUS-MINUS SIGN":             '±',
+	"MULTIPLICATION SIGN":         '×',
+	"DIVISION SIGN":               '÷',
+	"INFINITY":                    '∞',
+	"NOT EQUAL TO":                '≠',
+	"LESS-THAN OR EQUAL TO":       '≤',
+	"GREATER-THAN OR EQUAL TO":    '≥',
+	"LEFTWARDS ARROW":             '←',
+	"RIGHTWARDS ARROW":            '→',
+	"CHECK MARK":                  '✓',
+	"HEAVY CHECK MARK":            '✔',
+	"BALLOT X":                    '✗',
+	"BLACK STAR":                  '★',
+	"WHITE STAR":                  '☆',
+	"SNOWMAN":                     '☃',
+	"BLACK HEART SUIT":            '♥',
+	"HEAVY BLACK HEART":           '❤',
+	"GRINNING FACE":               '😀',
+	"FACE WITH TEARS OF JOY":      '😂',
+	"THUMBS UP SIGN":              '👍',
+	"REPLACEMENT CHARACTER":       '\ufffd',
+}
+
+// greekLetters lists the names of the Greek letters in the order of their
+// code points, the capital letters starting at U+0391 and the small ones
+// at U+03B1. The small final sigma has no capital letter.
+var greekLetters = []string{
+	"ALPHA", "BETA", "GAMMA", "DELTA", "EPSILON", "ZETA", "ETA", "THETA", "IOTA", "KAPPA", "LAMDA", "MU",
+	"NU", "XI", "OMICRON", "PI", "RHO", "FINAL SIGMA", "SIGMA", "TAU", "UPSILON", "PHI", "CHI", "PSI", "OMEGA",
+}
+
+// unicodeName returns the character of a Python \N{name} escape. Only the
+// names of ASCII letters and digits, Greek letters, CJK ideographs and the
+// characters of unicodeNames are known.
+func unicodeName(name string) (rune, bool) {
+	name = strings.ToUpper(name)
+	if r, ok := unicodeNames[name]; ok {
+		return r, true
+	}
+	if hex, ok := strings.CutPrefix(name, "CJK UNIFIED IDEOGRAPH-"); ok {
+		code, err := strconv.ParseUint(hex, 16, 32)
+		return rune(code), err == nil && utf8.ValidRune(rune(code))
+	}
+	for _, script := range []struct {
+		prefix         string
+		capital, small rune
+	}{
+		{"LATIN CAPITAL LETTER ", 'A', 0},
+		{"LATIN SMALL LETTER ", 0, 'a'},
+		{"GREEK CAPITAL LETTER ", 'Α', 0},
+		{"GREEK SMALL LETTER ", 0, 'α'},
+	} {
+		letter, ok := strings.CutPrefix(name, script.prefix)
+		if !ok {
+			continue
+		}
+		if len(letter) == 1 && letter[0] >= 'A' && letter[0] <= 'Z' && script.prefix[0] == 'L' {
+			return script.capital + script.small + rune(letter[0]-'A'), true
+		}
+		if j := slices.Index(greekLetters, letter); j != -1 && script.prefix[0] == 'G' && (script.small != 0 || letter != "FINAL SIGMA") {
+			return script.capital + script.small + rune(j), true
+		}
+		return 0, false
+	}
+	for j, digit := range []string{"ZERO", "ONE", "TWO", "THREE", "FOUR", "FIVE", "SIX", "SEVEN", "EIGHT", "NINE"} {
+		if name == "DIGIT "+digit {
+			return '0' + rune(j), true
+		}
+	}
+	return 0, false
+}
@@ -0,0 +1,153 @@
+package jsonrepair
+
+import (
+	"bytes"
+	"reflect"
+	"strings"
+	"testing"
+)
+
+var pythonTestInputs = []string{
+	`{'name': 'John', 'tags': ('a', 'b'), 'ids': {1, 2}, 'empty': ()}`,
+	`[b'bytes', r'C:\dir', u'text', Rb'\x00']`,
+	`{'when': datetime.datetime(2024, 1, 2, 3, 4, 5, tzinfo=datetime.timezone.utc), 'price': Decimal('9.99')}`,
+	`[float('nan'), inf, -inf, set(), frozenset({'x'})]`,
+	"{'text': 'caf\\xe9 \\N{BULLET} \\u2605 \\U0001F600 it\\'s'}",
+	`[(1, (2, 3)), {None, True}, {}]`,
+}
+
+func TestPythonDialect(t *testing.T) {
+	tests := []struct {
+		input    string
+		expected string
+	}{
+		{`(1, 2)`, `[1, 2]`},
+		{`(1,)`, `[1]`},
+		{`()`, `[]`},
+		{`{'point': (1, (2, 3))}`, `{"point": [1, [2, 3]]}`},
+		{`{1, 2, 3}`, `[1, 2, 3]`},
+		{`{'a', 'b'}`, `["a", "b"]`},
+		{`{None, True}`, `[null, true]`},
+		{`{}`, `{}`},
+		{`{1: 'a', 'b': 2}`, `{"1": "a", "b": 2}`},
+		{`[set(), frozenset(), tuple(), dict(), frozenset({1, 2})]`, `[[], [], [], {}, [1, 2]]`},
+		{`b'bytes'`, `"bytes"`},
+		{`u"text"`, `"text"`},
+		{`{b'key': B'value'}`, `{"key": "value"}`},
+		{`r'C:\dir\new'`, `"C:\\dir\\new"`},
+		{`rb'\d+'`, `"\\d+"`},
+		{`br'''raw\n'''`, `"raw\\n"`},
+		{`['a', b'b', r'c']`, `["a", "b", "c"]`},
+		{`'\x41\x00'`, `"A\u0000"`},
+		{`b'\xff'`, `"ÿ"`},
+		{`'\101\0\012'`, `"A\u0000\n"`},
+		{`'\U0001F600 \u2605'`, `"😀 \u2605"`},
+		{`'\N{BULLET} \N{em dash} \N{LATIN SMALL LETTER E} \N{GREEK SMALL LETTER LAMDA} \N{CJK UNIFIED IDEOGRAPH-4E00}'`, `"• — e λ 一"`},
+		{`'\N{UNKNOWN NAME} \N{LATIN SMALL LETTER E WITH ACUTE}'`, `"\\N{UNKNOWN NAME} \\N{LATIN SMALL LETTER E WITH ACUTE}"`},
+		{`'it\'s \a\v'`, `"it's \u0007\u000b"`},
+		{"'line \\\ncontinued'", `"line continued"`},
+		{`'''a \x41'''`, `"a A"`},
+		{`[nan, inf, -inf]`, `[null, null, null]`},
+		{`[float('nan'), float('-inf'), float('Infinity'), float('1.5'), float(2)]`, `[null, null, null, 1.5, 2]`},
+		{`[Decimal('1.20'), decimal.Decimal("-1E+2"), Decimal('NaN'), Decimal('+3')]`, `[1.20, -1E+2, null, 3]`},
+		{`datetime.datetime(2024, 1, 2, 3, 4, 5)`, `"2024-01-02T03:04:05"`},
+		{`datetime.datetime(2024, 1, 2, 3, 4, 5, 120)`, `"2024-01-02T03:04:05.000120"`},
+		{`datetime.datetime(2024, 1, 2, tzinfo=datetime.timezone.utc)`, `"2024-01-02T00:00:00Z"`},
+		{`datetime.datetime(2024, 1, 2, 3, 4, tzinfo=datetime.timezone(datetime.timedelta(days=-1, seconds=68400)))`, `"2024-01-02T03:04:00-05:00"`},
+		{`datetime.datetime(2024, 1, 2, 3, tzinfo=datetime.timezone(datetime.timedelta(seconds=19800)))`, `"2024-01-02T03:00:00+05:30"`},
+		{`datetime(year=2024, month=1, day=2, hour=3)`, `"2024-01-02T03:00:00"`},
+		{`datetime.date(2024, 1, 2)`, `"2024-01-02"`},
+		{`datetime.time(13, 30)`, `"13:30:00"`},
+		{`{'id': UUID('12345678-1234-5678-1234-567812345678')}`, `{"id": "12345678-1234-5678-1234-567812345678"}`},
+		{`{'a': [1, 2,], 'b': None}`, `{"a": [1, 2], "b": null}`},
+		{`((),)`, `[[]]`},
+		{`(1,(,2)`, `[1,"(",2]`},
+		{`(None(,1`, `[null,"(",1]`},
+		{`(Infinity(,/re/1`, `["Infinity(","/re/",1]`},
+		{`(Infinity(,1`, `["Infinity(",1]`},
+	}
+	for _, tt := range tests {
+		result, err := JSONRepairWithOptions(tt.input, Options{Dialect: DialectPython})
+		if err != nil || result != tt.expected {
+			t.Errorf("JSONRepairWithOptions(%q, DialectPython) = %q, %v, want %q", tt.input, result, err, tt.expected)
+		}
+	}
+}
+
+func TestPythonDialectOnlyWhenSelected(t *testing.T) {
+	for _, input := range []string{`[nan, inf]`, `'\x41'`, `[float('nan')]`} {
+		expected, _ := JSONRepairWithOptions(input, Options{Dialect: DialectPython})
+		if result, _ := JSONRepair(input); result == expected {
+			t.Errorf("JSONRepair(%q) = %q, repaired like DialectPython", input, result)
+		}
+	}
+}
+
+func TestPythonDialectReport(t *testing.T) {
+	input := `{'a': (1, b'x\x41'), 'b': Decimal('1.5')}`
+	result, err := JSONRepairWithReport(input, Options{Dialect: DialectPython})
+	if err != nil {
+		t.Fatalf("JSONRepairWithReport(%q) error: %v", input, err)
+	}
+	expected := []Repair{
+		{RepairQuotes, 1, 1, "'", `"`},
+		{RepairQuotes, 3, 3, "'", `"`},
+		{RepairPythonValue, 6, 6, "(", "["},
+		{RepairPythonString, 10, 10, "b", ""},
+		{RepairQuotes, 11, 10, "'", `"`},
+		{RepairPythonString, 13, 12, `\x41`, "A"},
+		{RepairQuotes, 17, 13, "'", `"`},
+		{RepairPythonValue, 18, 14, ")", "]"},
+		{RepairQuotes, 21, 17, "'", `"`},
+		{RepairQuotes, 23, 19, "'", `"`},
+		{RepairPythonValue, 26, 22, "Decimal('1.5')", "1.5"},
+	}
+	if !reflect.DeepEqual(result.Repairs, expected) {
+		t.Errorf("JSONRepairWithReport(%q).Repairs =\n%+v\nwant\n%+v", input, result.Repairs, expected)
+	}
+
+	for _, input := range pythonTestInputs {
+		result, err := JSONRepairWithReport(input, Options{Dialect: DialectPython})
+		if err != nil {
+			t.Fatalf("JSONRepairWithReport(%q) error: %v", input, err)
+		}
+		for _, r := range result.Repairs {
+			if input[r.InputOffset:r.InputOffset+len(r.Original)] != r.Original ||
+				result.Output[r.OutputOffset:r.OutputOffset+len(r.Replacement)] != r.Replacement {
+				t.Errorf("JSONRepairWithReport(%q): %+v does not match the input or the output %q", input, r, result.Output)
+			}
+		}
+	}
+
+	for _, kind := range []RepairKind{RepairPythonString, RepairPythonValue} {
+		if _, err := JSONRepairWithOptions(input, Options{Dialect: DialectPython, Disable: []RepairKind{kind}}); err == nil {
+			t.Errorf("JSONRepairWithOptions(%q) with %v disabled succeeded", input, kind)
+		}
+	}
+}
+
+func TestPythonDialectStream(t *testing.T) {
+	opts := Options{Dialect: DialectPython}
+	for _, input := range pythonTestInputs {
+		expected, expectedErr := JSONRepairWithOptions(input, opts)
+		if expectedErr != nil {
+			t.Fatalf("JSONRepairWithOptions(%q) error: %v", input, expectedErr)
+		}
+
+		var out bytes.Buffer
+		if err := RepairStreamWithOptions(strings.NewReader(input), &out, opts); err != nil || out.String() != expected {
+			t.Errorf("RepairStreamWithOptions(%q) = %q, %v, want %q", input, out.String(), err, expected)
+		}
+
+		r := NewRepairerWithOptions(opts)
+		for i := 0; i < len(input); i++ {
+			r.WriteString(input[i : i+1])
+			prefix := input[:i+1]
+			expected, expectedErr := JSONRepairWithOptions(prefix, opts)
+			result, err := r.Snapshot()
+			if (err == nil) != (expectedErr == nil) || result != expected {
+				t.Fatalf("Snapshot after %q = %q, %v, want %q, %v", prefix, result, err, expected, expectedErr)
+			}
+		}
+	}
+}
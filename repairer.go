@@ -0,0 +1,138 @@
+package jsonrepair
+
+import (
+	"slices"
+	"strings"
+)
+
+// Repairer repairs a JSON document that arrives in chunks, such as the
+// output of a language model streamed token by token. After every chunk,
+// Snapshot returns the repaired document of everything written so far.
+//
+// A Repairer remembers the last position from which the parse can be
+// continued regardless of what input follows, so each Snapshot only parses
+// the input from that position on rather than the whole document.
+//
+// A Repairer is not safe for concurrent use.
+type Repairer struct {
+	text       strings.Builder
+	opts       Options
+	checkpoint *checkpoint
+}
+
+// checkpoint captures the state of the parser between two members of a
+// container, which is all that is needed to continue parsing from there
+type checkpoint struct {
+	i       int     // Position in the input
+	output  string  // Output up to the checkpoint
+	pending string  // Whitespace to write before the next token
+	open    bool    // Whether the innermost container has no member written yet
+	stack   []frame // Containers enclosing the checkpoint
+	keys    []int   // Input offsets of the object keys before the checkpoint
+}
+
+// NewRepairer creates a Repairer with no input
+func NewRepairer() *Repairer {
+	return &Repairer{}
+}
+
+// Write appends a chunk of input. It always returns len(chunk) and a nil
+// error, so that a Repairer can be used as an io.Writer.
+func (r *Repairer) Write(chunk []byte) (int, error) {
+	return r.text.Write(chunk)
+}
+
+// WriteString appends a chunk of input
+func (r *Repairer) WriteString(chunk string) (int, error) {
+	return r.text.WriteString(chunk)
+}
+
+// Snapshot returns the repaired document of all input written so far, as
+// JSONRepair would return it if the input ended here.
+func (r *Repairer) Snapshot() (string, error) {
+	p := r.newParser()
+
+	resumed := false
+	var err error
+	if r.checkpoint != nil {
+		resumed, err = p.resume(r.checkpoint)
+	}
+	if !resumed {
+		p = r.newParser()
+		err = p.parse()
+	}
+
+	if p.checkpoint != nil {
+		r.checkpoint = p.checkpoint
+	}
+	if err != nil {
+		return "", err
+	}
+	return p.result()
+}
+
+// newParser creates a parser for the input written so far
+func (r *Repairer) newParser() *Parser {
+	p := &Parser{text: r.text.String(), partial: true, growing: true}
+	p.setOptions(r.opts)
+	return p
+}
+
+// saveCheckpoint records the current state of the parser
+func (p *Parser) saveCheckpoint() {
+	p.checkpoint = &checkpoint{
+		i:       p.i,
+		output:  p.output.String(),
+		pending: p.output.pending,
+		open:    p.output.open,
+		stack:   slices.Clone(p.stack),
+		keys:    slices.Clip(p.keys),
+	}
+}
+
+// resume continues a parse from cp. It reports false when the parse takes a
+// path that cannot be continued from a checkpoint, in which case the input
+// must be parsed from the start instead.
+func (p *Parser) resume(cp *checkpoint) (resumed bool, err error) {
+	defer p.recoverBailout(&err)
+	p.hookOutput()
+	p.checkInputSize()
+
+	p.i = cp.i
+	p.output.WriteString(cp.output)
+	p.output.pending, p.output.open = cp.pending, cp.open
+	p.stack = slices.Clone(cp.stack)
+	p.keys = slices.Clone(cp.keys)
+
+	// Unwind the containers from the innermost one outwards, continuing each
+	// where the parse of its child left it
+	initial := p.stack[len(p.stack)-1].initial
+	for len(p.stack) > 0 {
+		kind := p.stack[len(p.stack)-1].kind
+		processed := true
+		switch kind {
+		case frameObject:
+			processed = p.parseObjectMembers(initial)
+		case frameArray:
+			p.parseArrayMembers(initial)
+		case frameNewlineDelimited:
+			p.parseNewlineDelimitedValues(initial)
+		case frameFunctionCall:
+			p.parseFunctionCallEnd()
+		}
+		p.popFrame()
+
+		if !processed {
+			return false, nil
+		}
+		if kind == frameNewlineDelimited {
+			return true, p.parseEnd()
+		}
+
+		// Finish the parseValue call that parsed this container
+		p.parseWhitespaceAndSkipComments(true)
+		initial = false
+	}
+
+	return true, p.parseRootEnd()
+}
@@ -0,0 +1,63 @@
+package jsonrepair
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestRepairer(t *testing.T) {
+	inputs := append([]string{
+		`{"name": "John", "tags": ["a", "b"], "address": {"city": "Rome", "zip": "00100"}}`,
+		"```json\n{\"items\": [{\"id\": 1}, {\"id\": 2}]}\n```",
+		"{\"id\":1}\n{\"id\":2, \"list\": [1, 2, {\"a\": [3]}]}\n{\"id\":3}",
+		`callback({"data": [1, 2, 3], "more": {"x": true}});`,
+		`{"text": "He said "hi" to me", "next": [1, 2]}`,
+		`[{"a": 1}, {"b": NumberLong(2), "c": [ObjectId("abc"), {"d": 4}]}]`,
+	}, streamTestInputs...)
+
+	for _, input := range inputs {
+		r := NewRepairer()
+		for i := 0; i < len(input); i++ {
+			r.WriteString(input[i : i+1])
+			prefix := input[:i+1]
+
+			expected, expectedErr := JSONRepair(prefix)
+			result, err := r.Snapshot()
+			if (err == nil) != (expectedErr == nil) {
+				t.Fatalf("Snapshot after %q: error = %v, want %v", prefix, err, expectedErr)
+			}
+			if err != nil {
+				if err.Error() != expectedErr.Error() {
+					t.Fatalf("Snapshot after %q: error = %v, want %v", prefix, err, expectedErr)
+				}
+				continue
+			}
+			if result != expected {
+				t.Fatalf("Snapshot after %q = %q, want %q", prefix, result, expected)
+			}
+		}
+	}
+}
+
+func TestRepairerResumesFromCheckpoint(t *testing.T) {
+	r := NewRepairer()
+	r.WriteString(`{"items": [`)
+	for i := 0; i < 100; i++ {
+		r.WriteString(`{"id": 1, "name": "item"}, `)
+	}
+	if _, err := r.Snapshot(); err != nil {
+		t.Fatal(err)
+	}
+	if r.checkpoint == nil || r.checkpoint.i < r.text.Len()-100 {
+		t.Fatalf("Expected a checkpoint near the end of the input, got %+v", r.checkpoint)
+	}
+
+	r.WriteString(`{"id": 2, "name": "last`)
+	result, err := r.Snapshot()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !strings.HasSuffix(result, `{"id": 2, "name": "last"}]}`) {
+		t.Errorf("Unexpected snapshot %q", result[len(result)-40:])
+	}
+}
@@ -0,0 +1,74 @@
+package jsonrepair
+
+import "slices"
+
+// Repair describes a single change made to the input while repairing it
+type Repair struct {
+	Kind         RepairKind
+	InputOffset  int    // Byte offset in the input where the repair applies
+	OutputOffset int    // Byte offset in the output where Replacement starts
+	Original     string // Input text that was replaced, empty for an insertion
+	Replacement  string // Text written in its place, empty for a removal
+}
+
+// Result holds a repaired document together with the repairs applied to it
+type Result struct {
+	Output  string
+	Repairs []Repair // Ordered by InputOffset
+	Changed bool     // Whether Output differs from the input
+}
+
+// JSONRepairWithReport repairs a string containing an invalid JSON document
+// like JSONRepairWithOptions, and reports every repair it applied.
+//
+// Example:
+//
+//	result, err := jsonrepair.JSONRepairWithReport("{name: 'John'}", jsonrepair.Options{})
+//	for _, r := range result.Repairs {
+//		fmt.Printf("%d: %s %q -> %q\n", r.InputOffset, r.Kind, r.Original, r.Replacement)
+//	}
+func JSONRepairWithReport(text string, opts Options) (*Result, error) {
+	parser := NewParserWithOptions(text, opts)
+	parser.enableReport()
+	output, err := parser.Parse()
+	if err != nil {
+		return nil, err
+	}
+	return &Result{Output: output, Repairs: parser.report(), Changed: output != text}, nil
+}
+
+// enableReport makes the parser record every repair it applies
+func (p *Parser) enableReport() {
+	p.reporting = true
+}
+
+// report returns the repairs recorded by the parser ordered by input offset
+func (p *Parser) report() []Repair {
+	repairs := slices.Clone(p.repairs)
+	slices.SortStableFunc(repairs, func(a, b Repair) int {
+		return a.InputOffset - b.InputOffset
+	})
+	return repairs
+}
+
+// shiftRepairs updates the output offsets of the recorded repairs after
+// removed bytes at output position pos were replaced by inserted bytes.
+// Repairs whose replacement was removed as a whole are dropped.
+func (p *Parser) shiftRepairs(pos, removed, inserted int) {
+	kept := p.repairs[:0]
+	for j, r := range p.repairs {
+		switch {
+		case r.OutputOffset >= pos+removed:
+			r.OutputOffset += inserted - removed
+		case r.OutputOffset >= pos && r.Replacement != "" && r.OutputOffset+len(r.Replacement) <= pos+removed:
+			if j < p.checked {
+				p.checked--
+			}
+			continue
+		case r.OutputOffset > pos:
+			r.OutputOffset = pos
+		}
+		kept = append(kept, r)
+	}
+	p.repairs = kept
+}
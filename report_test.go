@@ -0,0 +1,107 @@
+package jsonrepair
+
+import (
+	"reflect"
+	"testing"
+)
+
+func TestJSONRepairWithReport(t *testing.T) {
+	tests := []struct {
+		input    string
+		expected []Repair
+	}{
+		{`{"a": [1, 2.5, "x", true, null]}`, nil},
+		{"{name: 'John'}", []Repair{
+			{RepairUnquotedString, 1, 1, "name", `"name"`},
+			{RepairQuotes, 7, 9, "'", `"`},
+			{RepairQuotes, 12, 14, "'", `"`},
+		}},
+		{`[1 2, None,]`, []Repair{
+			{RepairMissingComma, 3, 2, "", ","},
+			{RepairPythonConstant, 6, 7, "None", "null"},
+			{RepairTrailingComma, 10, 11, ",", ""},
+		}},
+		{"```json\n{\"a\": 1 // note\n}\n```", []Repair{
+			{RepairMarkdownFence, 0, 0, "```json", ""},
+			{RepairComment, 16, 9, "// note", ""},
+			{RepairMarkdownFence, 26, 12, "```", ""},
+		}},
+		{`{"a": [1, 2`, []Repair{
+			{RepairMissingBracket, 11, 11, "", "]"},
+			{RepairMissingBracket, 11, 12, "", "}"},
+		}},
+		{"{\"id\":1}\n{\"id\":2}", []Repair{
+			{RepairNewlineDelimited, 9, 10, "", ","},
+			{RepairNewlineDelimited, 9, 0, "", "[\n"},
+			{RepairNewlineDelimited, 17, 20, "", "\n]"},
+		}},
+		{`callback({"a": 1});`, []Repair{
+			{RepairFunctionCall, 0, 0, "callback(", ""},
+			{RepairFunctionCall, 17, 8, ");", ""},
+		}},
+		{`{"a": "b" + "c", "d": "e\x"}`, []Repair{
+			{RepairConcatenation, 10, 8, "+", ""},
+			{RepairInvalidEscape, 24, 19, `\x`, "x"},
+		}},
+		{`[1, 2, 3, ...]`, []Repair{
+			{RepairTrailingComma, 8, 8, ",", ""},
+			{RepairEllipsis, 10, 9, "...", ""},
+		}},
+		{`{"a": 1 "b": 2}`, []Repair{
+			{RepairMissingComma, 8, 7, "", ","},
+		}},
+		{"['''a\n\\'b''']", []Repair{
+			{RepairQuotes, 1, 1, "'''", `"`},
+			{RepairControlCharacter, 5, 3, "\n", `\n`},
+			{RepairInvalidEscape, 6, 5, `\`, ""},
+			{RepairQuotes, 9, 7, "'''", `"`},
+		}},
+	}
+
+	for _, tt := range tests {
+		result, err := JSONRepairWithReport(tt.input, Options{})
+		if err != nil {
+			t.Errorf("JSONRepairWithReport(%q) returned error: %v", tt.input, err)
+			continue
+		}
+		if expected := MustJSONRepair(tt.input); result.Output != expected {
+			t.Errorf("JSONRepairWithReport(%q).Output = %q, want %q", tt.input, result.Output, expected)
+		}
+		if !reflect.DeepEqual(result.Repairs, tt.expected) {
+			t.Errorf("JSONRepairWithReport(%q).Repairs =\n%+v\nwant\n%+v", tt.input, result.Repairs, tt.expected)
+		}
+	}
+}
+
+func TestJSONRepairWithReportOffsets(t *testing.T) {
+	inputs := append(streamTestInputs,
+		`["abc/*comment*/+"def"]`,
+		"{\n  \"greeting\": 'hello' +\n 'world'\n}",
+		"\"hello +\n \" world\"",
+		`{"a": "it's "quoted" here", "b": 1}`,
+		`[1, 2 ]`,
+	)
+	for _, input := range inputs {
+		result, err := JSONRepairWithReport(input, Options{})
+		if err != nil {
+			continue
+		}
+		for _, r := range result.Repairs {
+			if r.InputOffset < 0 || r.InputOffset+len(r.Original) > len(input) ||
+				input[r.InputOffset:r.InputOffset+len(r.Original)] != r.Original {
+				t.Errorf("JSONRepairWithReport(%q): %+v does not match the input", input, r)
+			}
+			if r.OutputOffset < 0 || r.OutputOffset+len(r.Replacement) > len(result.Output) ||
+				result.Output[r.OutputOffset:r.OutputOffset+len(r.Replacement)] != r.Replacement {
+				t.Errorf("JSONRepairWithReport(%q): %+v does not match the output %q", input, r, result.Output)
+			}
+		}
+	}
+}
+
+func TestJSONRepairWithReportError(t *testing.T) {
+	result, err := JSONRepairWithReport(`[1, 2,]`, Options{Disable: []RepairKind{RepairTrailingComma}})
+	if err == nil || result != nil {
+		t.Errorf("expected an error, got %+v", result)
+	}
+}
@@ -0,0 +1,575 @@
+package jsonrepair
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"math"
+	"reflect"
+	"slices"
+	"strconv"
+	"strings"
+)
+
+// Schema is a JSON Schema used to repair a document with
+// JSONRepairWithSchema. The following keywords of draft 2020-12 are
+// supported: type, enum, const, default, properties, required,
+// additionalProperties, items, prefixItems, anyOf, oneOf, $defs and local
+// $ref like "#/$defs/address". Other keywords are ignored.
+type Schema struct {
+	root *schema
+}
+
+// ParseSchema parses a JSON Schema
+//
+// Example:
+//
+//	schema, err := jsonrepair.ParseSchema([]byte(`{"type": "object", "properties": {"limit": {"type": "integer"}}}`))
+func ParseSchema(data []byte) (*Schema, error) {
+	var root schema
+	if err := json.Unmarshal(data, &root); err != nil {
+		return nil, err
+	}
+	if err := root.link(&root); err != nil {
+		return nil, err
+	}
+	return &Schema{root: &root}, nil
+}
+
+// SchemaResult holds a document repaired with a schema. The syntactic
+// repairs are reported separately from the changes made to conform to the
+// schema.
+type SchemaResult struct {
+	Output        string   // Document conforming to the schema
+	Repaired      string   // Document after the syntactic repairs only
+	Repairs       []Repair // Syntactic repairs, with output offsets in Repaired
+	SchemaRepairs []Repair // Changes made to conform to the schema, with output offsets in Output
+}
+
+// JSONRepairWithSchema repairs a string containing an invalid JSON document
+// like JSONRepairWithReport, then changes the repaired document to conform
+// to schema:
+//
+//   - RepairTypeCoercion: a string, number or boolean converted to the type
+//     of the schema, like "5" to 5 for {"type": "integer"}
+//   - RepairWrapArray: a single value wrapped into an array
+//   - RepairEnum: a value replaced by the nearest value of enum or const
+//   - RepairDefault: a missing required property added with its default
+//   - RepairAdditionalProperty: a property removed because additionalProperties
+//     is false, or its schema is false
+//
+// The input offsets of the schema repairs are those in text of the values
+// they change. Values that cannot be fixed are left as they are: the output
+// is not guaranteed to be valid against the schema. Output that is still
+// not valid JSON after the syntactic repairs is not changed either.
+//
+// Example:
+//
+//	result, err := jsonrepair.JSONRepairWithSchema("{limit: '10', unit: 'Kilo'}", schema, jsonrepair.Options{})
+func JSONRepairWithSchema(text string, schema *Schema, opts Options) (*SchemaResult, error) {
+	parser := NewParserWithOptions(text, opts)
+	parser.enableReport()
+	repaired, err := parser.Parse()
+	if err != nil {
+		return nil, err
+	}
+
+	if !json.Valid([]byte(repaired)) {
+		// Some input is repaired into a document that is still not valid,
+		// which is left as it is
+		return &SchemaResult{Output: repaired, Repaired: repaired, Repairs: parser.report()}, nil
+	}
+	r := schemaRepairer{document: document{data: []byte(repaired), repairs: parser.report()}, parser: parser}
+	root, err := r.parse()
+	if err != nil {
+		return nil, err
+	}
+	r.repair(root, schema.root)
+	output, repairs := r.apply()
+	return &SchemaResult{Output: output, Repaired: repaired, Repairs: r.repairs, SchemaRepairs: repairs}, nil
+}
+
+// schema is a parsed JSON Schema
+type schema struct {
+	Ref                  string             `json:"$ref"`
+	Defs                 map[string]*schema `json:"$defs"`
+	Definitions          map[string]*schema `json:"definitions"`
+	Type                 schemaTypes        `json:"type"`
+	Enum                 []json.RawMessage  `json:"enum"`
+	Const                json.RawMessage    `json:"const"`
+	Default              json.RawMessage    `json:"default"`
+	Properties           map[string]*schema `json:"properties"`
+	Required             []string           `json:"required"`
+	AdditionalProperties *schema            `json:"additionalProperties"`
+	Items                *schema            `json:"items"`
+	PrefixItems          []*schema          `json:"prefixItems"`
+	AnyOf                []*schema          `json:"anyOf"`
+	OneOf                []*schema          `json:"oneOf"`
+
+	never bool    // The schema false, which no value is valid against
+	ref   *schema // Schema referred to by Ref
+}
+
+// UnmarshalJSON implements json.Unmarshaler, accepting the boolean schemas
+// true and false
+func (s *schema) UnmarshalJSON(data []byte) error {
+	switch string(data) {
+	case "true":
+		return nil
+	case "false":
+		s.never = true
+		return nil
+	}
+	type plain schema
+	return json.Unmarshal(data, (*plain)(s))
+}
+
+// schemaTypes is the type keyword, a single type or a list of types
+type schemaTypes []string
+
+// UnmarshalJSON implements json.Unmarshaler
+func (t *schemaTypes) UnmarshalJSON(data []byte) error {
+	var name string
+	if err := json.Unmarshal(data, &name); err == nil {
+		*t = schemaTypes{name}
+		return nil
+	}
+	return json.Unmarshal(data, (*[]string)(t))
+}
+
+// link resolves the $ref of s and its subschemas against root
+func (s *schema) link(root *schema) error {
+	if s == nil {
+		return nil
+	}
+	if s.Ref != "" {
+		target, err := root.lookup(s.Ref)
+		if err != nil {
+			return err
+		}
+		s.ref = target
+	}
+	for _, sub := range s.subschemas() {
+		if err := sub.link(root); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// subschemas returns the schemas nested in s
+func (s *schema) subschemas() []*schema {
+	subs := []*schema{s.AdditionalProperties, s.Items}
+	subs = append(subs, s.PrefixItems...)
+	subs = append(subs, s.AnyOf...)
+	subs = append(subs, s.OneOf...)
+	for _, m := range []map[string]*schema{s.Defs, s.Definitions, s.Properties} {
+		for _, sub := range m {
+			subs = append(subs, sub)
+		}
+	}
+	return subs
+}
+
+// pointerUnescaper unescapes a token of a JSON pointer
+var pointerUnescaper = strings.NewReplacer("~1", "/", "~0", "~")
+
+// lookup returns the subschema referred to by a local reference like
+// "#/$defs/address"
+func (s *schema) lookup(ref string) (*schema, error) {
+	pointer, ok := strings.CutPrefix(ref, "#")
+	if !ok {
+		return nil, fmt.Errorf("unsupported $ref %q in schema", ref)
+	}
+
+	target := s
+	tokens := strings.Split(pointer, "/")[1:]
+	for j := 0; j < len(tokens) && target != nil; j++ {
+		token := pointerUnescaper.Replace(tokens[j])
+		switch token {
+		case "items":
+			target = target.Items
+			continue
+		case "additionalProperties":
+			target = target.AdditionalProperties
+			continue
+		}
+
+		if j++; j == len(tokens) {
+			return nil, fmt.Errorf("unresolved $ref %q in schema", ref)
+		}
+		name := pointerUnescaper.Replace(tokens[j])
+		switch token {
+		case "$defs":
+			target = target.Defs[name]
+		case "definitions":
+			target = target.Definitions[name]
+		case "properties":
+			target = target.Properties[name]
+		case "prefixItems", "anyOf", "oneOf":
+			list := map[string][]*schema{"prefixItems": target.PrefixItems, "anyOf": target.AnyOf, "oneOf": target.OneOf}[token]
+			index, err := strconv.Atoi(name)
+			if err != nil || index < 0 || index >= len(list) {
+				return nil, fmt.Errorf("unresolved $ref %q in schema", ref)
+			}
+			target = list[index]
+		default:
+			return nil, fmt.Errorf("unsupported $ref %q in schema", ref)
+		}
+	}
+	if target == nil {
+		return nil, fmt.Errorf("unresolved $ref %q in schema", ref)
+	}
+	return target, nil
+}
+
+// maxRefDepth limits the references and the anyOf or oneOf branches
+// followed to resolve a schema, which stops a cycle like {"$ref": "#"}
+const maxRefDepth = 32
+
+// resolve follows the $ref of s
+func (s *schema) resolve() *schema {
+	for j := 0; s != nil && s.ref != nil && j < maxRefDepth; j++ {
+		s = s.ref
+	}
+	return s
+}
+
+// accepts reports whether the type of s allows the value n
+func (s *schema) accepts(d *document, n *node) bool {
+	if len(s.Type) == 0 {
+		return true
+	}
+	for _, t := range s.Type {
+		switch {
+		case t == nodeType(n),
+			t == "number" && n.kind == '0',
+			t == "integer" && n.kind == '0' && isInteger(d.raw(n)):
+			return true
+		}
+	}
+	return false
+}
+
+// nodeType returns the JSON Schema type of node n, with numbers reported as
+// number
+func nodeType(n *node) string {
+	switch n.kind {
+	case '{':
+		return "object"
+	case '[':
+		return "array"
+	case '"':
+		return "string"
+	case '0':
+		return "number"
+	case 't', 'f':
+		return "boolean"
+	}
+	return "null"
+}
+
+// isInteger reports whether the JSON number text has no fraction, like 5
+// or 5.0
+func isInteger(text string) bool {
+	f, err := strconv.ParseFloat(text, 64)
+	return err == nil && f == math.Trunc(f)
+}
+
+// edit replaces the bytes start to end of a document by text
+type edit struct {
+	kind       RepairKind
+	start, end int
+	text       string
+}
+
+// schemaRepairer changes a repaired document to conform to a schema
+type schemaRepairer struct {
+	document
+	parser *Parser
+	edits  []edit
+	wrap   *node // Value being wrapped into an array, which is not wrapped again
+}
+
+// edit replaces the bytes start to end of the document by text
+func (r *schemaRepairer) edit(kind RepairKind, start, end int, text string) {
+	r.edits = append(r.edits, edit{kind: kind, start: start, end: end, text: text})
+}
+
+// replace replaces the value n by text
+func (r *schemaRepairer) replace(kind RepairKind, n *node, text string) {
+	if r.parser.allowed(kind) {
+		r.edit(kind, n.start, n.end, text)
+	}
+}
+
+// apply returns the document with the edits applied, and the repairs they
+// made
+func (r *schemaRepairer) apply() (string, []Repair) {
+	slices.SortStableFunc(r.edits, func(a, b edit) int {
+		if a.start != b.start {
+			return a.start - b.start
+		}
+		return a.end - b.end
+	})
+
+	var b strings.Builder
+	var repairs []Repair
+	last := 0
+	for _, e := range r.edits {
+		b.Write(r.data[last:e.start])
+		repairs = append(repairs, Repair{
+			Kind:         e.kind,
+			InputOffset:  r.inputOffset(e.start),
+			OutputOffset: b.Len(),
+			Original:     string(r.data[e.start:e.end]),
+			Replacement:  e.text,
+		})
+		b.WriteString(e.text)
+		last = e.end
+	}
+	b.Write(r.data[last:])
+	return b.String(), repairs
+}
+
+// repair changes the value n to conform to schema s
+func (r *schemaRepairer) repair(n *node, s *schema) {
+	s = r.choose(n, s)
+	if s == nil {
+		return
+	}
+
+	if s.Const != nil {
+		r.repairEnum(n, []json.RawMessage{s.Const})
+		return
+	}
+	if len(s.Enum) > 0 {
+		r.repairEnum(n, s.Enum)
+		return
+	}
+	if !s.accepts(&r.document, n) {
+		r.repairType(n, s)
+		return
+	}
+
+	switch n.kind {
+	case '{':
+		r.repairObject(n, s)
+	case '[':
+		for j, item := range n.items {
+			if j < len(s.PrefixItems) {
+				r.repair(item, s.PrefixItems[j])
+			} else {
+				r.repair(item, s.Items)
+			}
+		}
+	}
+}
+
+// choose resolves s and the anyOf or oneOf branches it has for the value
+// n, and returns the schema to repair n with. At most maxRefDepth branches
+// are followed, which stops a cycle like {"anyOf": [{"$ref": "#"}]}.
+func (r *schemaRepairer) choose(n *node, s *schema) *schema {
+	for j := 0; j < maxRefDepth; j++ {
+		s = s.resolve()
+		if s == nil {
+			return nil
+		}
+		branches := append(slices.Clip(s.AnyOf), s.OneOf...)
+		if len(branches) == 0 {
+			return s
+		}
+		s = r.branch(n, branches)
+	}
+	return nil
+}
+
+// branch returns the first of the anyOf or oneOf branches whose type
+// allows the value n, or else the first branch
+func (r *schemaRepairer) branch(n *node, branches []*schema) *schema {
+	for _, b := range branches {
+		if b := b.resolve(); b != nil && !b.never && b.accepts(&r.document, n) {
+			return b
+		}
+	}
+	return branches[0]
+}
+
+// repairEnum replaces the value n by the nearest of the allowed values
+// when it is none of them
+func (r *schemaRepairer) repairEnum(n *node, values []json.RawMessage) {
+	if n.kind == '{' || n.kind == '[' {
+		return
+	}
+	var value any
+	_ = json.Unmarshal(r.data[n.start:n.end], &value)
+
+	best, bestScore := -1, math.Inf(1)
+	for j, raw := range values {
+		var allowed any
+		if err := json.Unmarshal(raw, &allowed); err != nil {
+			continue
+		}
+		if reflect.DeepEqual(value, allowed) {
+			return
+		}
+		if score := enumDistance(value, allowed); score < bestScore || best == -1 {
+			best, bestScore = j, score
+		}
+	}
+	if best != -1 {
+		r.replace(RepairEnum, n, compactJSON(values[best]))
+	}
+}
+
+// enumDistance returns how far value is from the allowed value of an enum.
+// Numbers are compared by their difference, other values by the edit
+// distance of their text.
+func enumDistance(value, allowed any) float64 {
+	switch a := allowed.(type) {
+	case map[string]any, []any:
+		return math.Inf(1)
+	case float64:
+		if v, ok := value.(float64); ok {
+			return math.Abs(v - a)
+		}
+		if s, ok := value.(string); ok {
+			if v, err := strconv.ParseFloat(strings.TrimSpace(s), 64); err == nil {
+				return math.Abs(v - a)
+			}
+		}
+	}
+	return float64(levenshtein(strings.ToLower(enumText(value)), strings.ToLower(enumText(allowed))))
+}
+
+// enumText returns a string value as is, and other values as JSON
+func enumText(value any) string {
+	if s, ok := value.(string); ok {
+		return s
+	}
+	text, _ := json.Marshal(value)
+	return string(text)
+}
+
+// levenshtein returns the number of single rune edits needed to turn a
+// into b
+func levenshtein(a, b string) int {
+	ra, rb := []rune(a), []rune(b)
+	row := make([]int, len(rb)+1)
+	for j := range row {
+		row[j] = j
+	}
+	for i := 1; i <= len(ra); i++ {
+		prev := row[0]
+		row[0] = i
+		for j := 1; j <= len(rb); j++ {
+			cost := 1
+			if ra[i-1] == rb[j-1] {
+				cost = 0
+			}
+			prev, row[j] = row[j], min(row[j]+1, row[j-1]+1, prev+cost)
+		}
+	}
+	return row[len(rb)]
+}
+
+// compactJSON returns the JSON text raw without insignificant whitespace
+func compactJSON(raw json.RawMessage) string {
+	var b bytes.Buffer
+	if err := json.Compact(&b, raw); err != nil {
+		return string(raw)
+	}
+	return b.String()
+}
+
+// repairType converts the value n to a type allowed by s. Scalar
+// conversions are preferred over wrapping the value into an array.
+func (r *schemaRepairer) repairType(n *node, s *schema) {
+	for _, t := range s.Type {
+		var text string
+		var ok bool
+		switch t {
+		case "integer":
+			text, ok = r.numberValue(n, true)
+		case "number":
+			text, ok = r.numberValue(n, false)
+		case "string":
+			text, ok = r.stringValue(n)
+		case "boolean":
+			text, ok = r.boolValue(n)
+		}
+		if ok {
+			r.replace(RepairTypeCoercion, n, text)
+			return
+		}
+	}
+
+	if slices.Contains(s.Type, "array") && n != r.wrap && r.parser.allowed(RepairWrapArray) {
+		r.edit(RepairWrapArray, n.start, n.start, "[")
+		r.wrap = n
+		if len(s.PrefixItems) > 0 {
+			r.repair(n, s.PrefixItems[0])
+		} else {
+			r.repair(n, s.Items)
+		}
+		r.wrap = nil
+		r.edit(RepairWrapArray, n.end, n.end, "]")
+		return
+	}
+
+	if n.kind == 'n' && s.Default != nil {
+		r.replace(RepairDefault, n, compactJSON(s.Default))
+	}
+}
+
+// repairObject repairs the properties of object n, removes the forbidden
+// ones and adds the missing required ones that have a default
+func (r *schemaRepairer) repairObject(n *node, s *schema) {
+	keep := make([]bool, len(n.members))
+	present := make(map[string]bool)
+	for j, m := range n.members {
+		present[m.key] = true
+		sub, ok := s.Properties[m.key]
+		if !ok {
+			sub = s.AdditionalProperties
+		}
+		if sub := sub.resolve(); sub != nil && sub.never && r.parser.allowed(RepairAdditionalProperty) {
+			continue
+		}
+		keep[j] = true
+		r.repair(m.value, sub)
+	}
+
+	// Remove each forbidden property with the comma separating it from the
+	// previous property, or from the next one when it is the first
+	last := -1
+	for j, m := range n.members {
+		switch {
+		case keep[j]:
+			last = j
+		case last != -1:
+			r.edit(RepairAdditionalProperty, n.members[j-1].value.end, m.value.end, "")
+		case j+1 < len(n.members):
+			r.edit(RepairAdditionalProperty, m.start, n.members[j+1].start, "")
+		default:
+			r.edit(RepairAdditionalProperty, m.start, m.value.end, "")
+		}
+	}
+
+	if !r.parser.allowed(RepairDefault) {
+		return
+	}
+	pos, separator := n.start+1, ""
+	if last != -1 {
+		pos, separator = n.members[last].value.end, ", "
+	}
+	for _, name := range s.Required {
+		sub := s.Properties[name].resolve()
+		if present[name] || sub == nil || sub.Default == nil {
+			continue
+		}
+		key, _ := json.Marshal(name)
+		r.edit(RepairDefault, pos, pos, separator+string(key)+": "+compactJSON(sub.Default))
+		separator = ", "
+	}
+}
@@ -0,0 +1,197 @@
+package jsonrepair
+
+import (
+	"reflect"
+	"strings"
+	"testing"
+)
+
+const testToolSchema = `{
+	"type": "object",
+	"properties": {
+		"query": {"type": "string"},
+		"limit": {"type": "integer", "default": 10},
+		"exact": {"type": "boolean"},
+		"unit": {"enum": ["kg", "lb", "g"]},
+		"level": {"enum": [1, 2, 3]},
+		"tags": {"type": "array", "items": {"type": "string"}},
+		"sort": {"$ref": "#/$defs/sort"},
+		"mode": {"type": "string", "default": "fast"},
+		"version": {"const": "v1"},
+		"point": {"type": "array", "prefixItems": [{"type": "number"}, {"type": "number"}]},
+		"value": {"anyOf": [{"type": "integer"}, {"type": "object", "additionalProperties": false}]}
+	},
+	"required": ["query", "limit", "mode"],
+	"additionalProperties": false,
+	"$defs": {
+		"sort": {"type": "object", "properties": {"desc": {"type": "boolean", "default": false}}, "required": ["desc"]}
+	}
+}`
+
+func TestJSONRepairWithSchema(t *testing.T) {
+	schema, err := ParseSchema([]byte(testToolSchema))
+	if err != nil {
+		t.Fatalf("ParseSchema returned error: %v", err)
+	}
+
+	tests := []struct {
+		input    string
+		expected string
+		kinds    []RepairKind
+	}{
+		{
+			`{"query": "go", "limit": 5, "mode": "slow"}`,
+			`{"query": "go", "limit": 5, "mode": "slow"}`,
+			nil,
+		},
+		{
+			`{query: 42, limit: '5', exact: "yes", mode: "slow"}`,
+			`{"query": "42", "limit": 5, "exact": true, "mode": "slow"}`,
+			[]RepairKind{RepairTypeCoercion, RepairTypeCoercion, RepairTypeCoercion},
+		},
+		{
+			`{"query": "go", "unit": "KG", "level": "2", "version": "v2"}`,
+			`{"query": "go", "unit": "kg", "level": 2, "version": "v1", "limit": 10, "mode": "fast"}`,
+			[]RepairKind{RepairEnum, RepairEnum, RepairEnum, RepairDefault, RepairDefault},
+		},
+		{
+			`{"query": "go", "unit": "LBS", "level": 7}`,
+			`{"query": "go", "unit": "lb", "level": 3, "limit": 10, "mode": "fast"}`,
+			[]RepairKind{RepairEnum, RepairEnum, RepairDefault, RepairDefault},
+		},
+		{
+			`{"extra": 1, "query": "go", "other": [1], "limit": 2.0, "mode": "m", "last": {}}`,
+			`{"query": "go", "limit": 2.0, "mode": "m"}`,
+			[]RepairKind{RepairAdditionalProperty, RepairAdditionalProperty, RepairAdditionalProperty},
+		},
+		{
+			`{"extra": 1}`,
+			`{"limit": 10, "mode": "fast"}`,
+			[]RepairKind{RepairDefault, RepairDefault, RepairAdditionalProperty},
+		},
+		{
+			`{"query": "go", "limit": 1, "mode": "m", "tags": "news", "point": ["1", 2], "sort": {}}`,
+			`{"query": "go", "limit": 1, "mode": "m", "tags": ["news"], "point": [1, 2], "sort": {"desc": false}}`,
+			[]RepairKind{RepairWrapArray, RepairWrapArray, RepairTypeCoercion, RepairDefault},
+		},
+		{
+			`{"query": "go", "limit": null, "mode": "m", "value": "4", "point": 1}`,
+			`{"query": "go", "limit": 10, "mode": "m", "value": 4, "point": [1]}`,
+			[]RepairKind{RepairDefault, RepairTypeCoercion, RepairWrapArray, RepairWrapArray},
+		},
+		{
+			`{"query": "go", "limit": 1, "mode": "m", "value": {"a": 1}}`,
+			`{"query": "go", "limit": 1, "mode": "m", "value": {}}`,
+			[]RepairKind{RepairAdditionalProperty},
+		},
+	}
+
+	for _, tt := range tests {
+		result, err := JSONRepairWithSchema(tt.input, schema, Options{})
+		if err != nil {
+			t.Errorf("JSONRepairWithSchema(%q) returned error: %v", tt.input, err)
+			continue
+		}
+		if result.Output != tt.expected {
+			t.Errorf("JSONRepairWithSchema(%q) = %s, want %s", tt.input, result.Output, tt.expected)
+		}
+		var kinds []RepairKind
+		for _, r := range result.SchemaRepairs {
+			kinds = append(kinds, r.Kind)
+			if !strings.HasPrefix(result.Output[r.OutputOffset:], r.Replacement) {
+				t.Errorf("JSONRepairWithSchema(%q): %q not found at output offset %d", tt.input, r.Replacement, r.OutputOffset)
+			}
+		}
+		if !reflect.DeepEqual(kinds, tt.kinds) {
+			t.Errorf("JSONRepairWithSchema(%q) schema repairs = %v, want %v", tt.input, kinds, tt.kinds)
+		}
+	}
+}
+
+func TestJSONRepairWithSchemaReport(t *testing.T) {
+	schema, err := ParseSchema([]byte(`{"type": "object", "properties": {"n": {"type": "integer"}}}`))
+	if err != nil {
+		t.Fatalf("ParseSchema returned error: %v", err)
+	}
+	result, err := JSONRepairWithSchema(`{n: '5'}`, schema, Options{})
+	if err != nil {
+		t.Fatalf("JSONRepairWithSchema returned error: %v", err)
+	}
+
+	expected := &SchemaResult{
+		Output:   `{"n": 5}`,
+		Repaired: `{"n": "5"}`,
+		Repairs: []Repair{
+			{RepairUnquotedString, 1, 1, "n", `"n"`},
+			{RepairQuotes, 4, 6, "'", `"`},
+			{RepairQuotes, 6, 8, "'", `"`},
+		},
+		SchemaRepairs: []Repair{
+			{RepairTypeCoercion, 4, 6, `"5"`, "5"},
+		},
+	}
+	if !reflect.DeepEqual(result, expected) {
+		t.Errorf("JSONRepairWithSchema = %+v, want %+v", result, expected)
+	}
+
+	result, err = JSONRepairWithSchema(`{n: '5'}`, schema, Options{Disable: []RepairKind{RepairTypeCoercion}})
+	if err != nil || result.Output != `{"n": "5"}` || len(result.SchemaRepairs) != 0 {
+		t.Errorf("JSONRepairWithSchema with coercion disabled = %+v, %v", result, err)
+	}
+}
+
+func TestParseSchema(t *testing.T) {
+	valid := []string{
+		`true`,
+		`{"$ref": "#"}`,
+		`{"type": ["string", "null"], "items": false}`,
+		`{"properties": {"a/b": {"type": "string"}, "c": {"$ref": "#/properties/a~1b"}}}`,
+		`{"anyOf": [{"type": "string"}], "items": {"$ref": "#/anyOf/0"}}`,
+	}
+	for _, input := range valid {
+		if _, err := ParseSchema([]byte(input)); err != nil {
+			t.Errorf("ParseSchema(%s) returned error: %v", input, err)
+		}
+	}
+
+	invalid := []string{
+		`{"type": 1}`,
+		`{"$ref": "https://example.com/schema.json"}`,
+		`{"$ref": "#/$defs/missing"}`,
+		`{"$ref": "#/anyOf/1", "anyOf": [{}]}`,
+		`{"$ref": "#/$defs"}`,
+	}
+	for _, input := range invalid {
+		if _, err := ParseSchema([]byte(input)); err == nil {
+			t.Errorf("ParseSchema(%s): expected an error", input)
+		}
+	}
+
+	schema, _ := ParseSchema([]byte(`{"$ref": "#"}`))
+	if result, err := JSONRepairWithSchema(`[1, 2`, schema, Options{}); err != nil || result.Output != `[1, 2]` {
+		t.Errorf("JSONRepairWithSchema with a reference cycle = %+v, %v", result, err)
+	}
+
+	for _, input := range []string{`{"anyOf": [{"$ref": "#"}]}`, `{"oneOf": [{"$ref": "#/oneOf/1"}, {"anyOf": [{"$ref": "#"}]}]}`} {
+		schema, err := ParseSchema([]byte(input))
+		if err != nil {
+			t.Fatalf("ParseSchema(%s) returned error: %v", input, err)
+		}
+		if result, err := JSONRepairWithSchema(`{"a":1}`, schema, Options{}); err != nil || result.Output != `{"a":1}` {
+			t.Errorf("JSONRepairWithSchema with the branch cycle %s = %+v, %v", input, result, err)
+		}
+	}
+}
+
+func TestJSONRepairWithSchemaInvalidOutput(t *testing.T) {
+	schema, _ := ParseSchema([]byte(`{"type": "object", "properties": {"a": {"type": "integer"}}}`))
+	// These are repaired without an error into output that is not valid
+	// JSON, which is left as it is
+	for _, input := range []string{`f(`, `{undefined`, `{*/`} {
+		expected, _ := JSONRepair(input)
+		result, err := JSONRepairWithSchema(input, schema, Options{})
+		if err != nil || result.Output != expected || len(result.SchemaRepairs) != 0 {
+			t.Errorf("JSONRepairWithSchema(%q) = %+v, %v, want %q", input, result, err, expected)
+		}
+	}
+}
@@ -0,0 +1,174 @@
+package jsonrepair
+
+import (
+	"errors"
+	"io"
+	"strings"
+	"unicode/utf8"
+	"unsafe"
+)
+
+// Buffer sizes used when streaming. They are variables so that tests can
+// exercise the flushing logic with small documents.
+var (
+	// streamChunkSize is the minimum number of bytes read from the input at once
+	streamChunkSize = 64 * 1024
+	// streamBufferSize is the number of output bytes kept in memory so that
+	// repairs like stripping a trailing comma can still rewrite them
+	streamBufferSize = 64 * 1024
+)
+
+// RepairStream repairs the JSON document read from r and writes the result to w.
+//
+// Unlike JSONRepair, the input is not loaded into memory as a whole: input is
+// released once it has been parsed, and output is written to w as soon as it
+// can no longer be changed by a repair. Memory use is bounded by the size of
+// the largest string or other single token in the document plus a fixed
+// buffer. Errors report the same positions as JSONRepair would for the same
+// input.
+//
+// Newline delimited JSON can only be repaired when its first value fits in
+// the output buffer, as the opening bracket must be inserted in front of it.
+func RepairStream(r io.Reader, w io.Writer) error {
+	return repairStream(r, w, Options{})
+}
+
+// repairStream implements RepairStream and RepairStreamWithOptions
+func repairStream(r io.Reader, w io.Writer, opts Options) error {
+	p := &Parser{reader: r, growing: true}
+	p.setOptions(opts)
+	if !p.buffered() {
+		p.output.w = w
+	}
+	if err := p.parse(); err != nil {
+		if p.err != nil {
+			return p.err
+		}
+		return err
+	}
+	if p.err != nil {
+		return p.err
+	}
+	if p.buffered() {
+		// Nothing could be written before the end
+		err := p.finish()
+		if err == nil {
+			_, err = w.Write(p.output.buf)
+		}
+		return err
+	}
+	p.writeTrailingWhitespace()
+	p.output.flush(0)
+	return p.output.err
+}
+
+// has reports whether index j lies within the input. When streaming, it
+// reads ahead until the whole rune starting at j is buffered or the input
+// is exhausted. For partial input, it notes when the answer may change once
+// more input is appended.
+func (p *Parser) has(j int) bool {
+	if !p.growing || j+utf8.UTFMax <= len(p.text) {
+		// Kept apart from reading ahead so that the common cases are inlined
+		return j < len(p.text)
+	}
+	return p.hasNearEnd(j)
+}
+
+// hasNearEnd is has for an index within a rune of the end of the input
+func (p *Parser) hasNearEnd(j int) bool {
+	for p.reader != nil && !p.eof && j+utf8.UTFMax > len(p.text) {
+		p.fill()
+	}
+	if p.partial && j+utf8.UTFMax > len(p.text) {
+		p.atEdge = true
+	}
+	return j < len(p.text)
+}
+
+// fill reads the next chunk of input into the buffer
+func (p *Parser) fill() {
+	size := max(streamChunkSize, len(p.buf))
+	if cap(p.buf)-len(p.buf) < size {
+		// Allocate a new array rather than growing in place: p.text and
+		// strings sliced from it must never see their bytes change.
+		grown := make([]byte, len(p.buf), len(p.buf)+size)
+		copy(grown, p.buf)
+		p.buf = grown
+	}
+	n, err := p.reader.Read(p.buf[len(p.buf):cap(p.buf)])
+	p.buf = p.buf[:len(p.buf)+n]
+	p.setText()
+	p.checkInputSize()
+	if err != nil {
+		p.eof = true
+		if !errors.Is(err, io.EOF) {
+			p.err = err
+		}
+	}
+}
+
+// setText points p.text at the buffered input without copying it
+func (p *Parser) setText() {
+	if len(p.buf) == 0 {
+		p.text = ""
+		return
+	}
+	p.text = unsafe.String(&p.buf[0], len(p.buf))
+}
+
+// inputLen returns the total length of the input
+func (p *Parser) inputLen() int {
+	if p.reader != nil && !p.eof {
+		n, err := io.Copy(io.Discard, p.reader)
+		p.eof = true
+		if err != nil {
+			p.err = err
+		}
+		return p.offset + len(p.text) + int(n)
+	}
+	return p.offset + len(p.text)
+}
+
+// safePoint is called between the members of an object or array, where no
+// pending repair refers to earlier input and only a short tail of the output
+// can still be rewritten. When streaming, it flushes final output and
+// releases input that has already been parsed. When repairing incrementally,
+// it records a checkpoint to resume from once more input arrives.
+func (p *Parser) safePoint(initial bool) {
+	if p.hold > 0 {
+		return
+	}
+	p.stack[len(p.stack)-1].initial = initial
+	p.checkRepairs()
+	if p.partial && !p.atEdge {
+		p.saveCheckpoint()
+	}
+	if p.reader == nil || p.buffered() {
+		// Buffered output may still refer to any of the input
+		return
+	}
+
+	if len(p.output.buf) >= 2*streamBufferSize {
+		p.output.flush(streamBufferSize)
+	}
+
+	if p.i >= streamChunkSize && p.i <= len(p.buf) {
+		rest := make([]byte, len(p.buf)-p.i, max(len(p.buf)-p.i, streamChunkSize))
+		copy(rest, p.buf[p.i:])
+		p.countLines(p.text[:p.i])
+		p.buf = rest
+		p.offset += p.i
+		p.i = 0
+		p.setText()
+	}
+}
+
+// countLines advances the line and column of the discarded input past text
+func (p *Parser) countLines(text string) {
+	if nl := strings.LastIndexByte(text, '\n'); nl != -1 {
+		p.lines += strings.Count(text, "\n")
+		p.column = 0
+		text = text[nl+1:]
+	}
+	p.column += utf8.RuneCountInString(text)
+}
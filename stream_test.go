@@ -0,0 +1,185 @@
+package jsonrepair
+
+import (
+	"bytes"
+	"errors"
+	"io"
+	"strings"
+	"testing"
+	"testing/iotest"
+	"time"
+)
+
+// streamTestInputs covers the repairs of JSONRepair that involve rewriting
+// output or looking ahead in the input
+var streamTestInputs = []string{
+	`{"a":2.3e100,"b":"str","c":null,"d":false,"e":[1,2,3]}`,
+	"  { \n } \t ",
+	"{name: 'John', age: 30}",
+	"[1, 2, 3, 4, 5,]",
+	`{"a": 1 "b": 2}`,
+	`{"a" 2}`,
+	`[1 2 3]`,
+	`{"a": [1, 2`,
+	`{"message": "hello`,
+	`{"name": "John", /* comment */ "age": 30}`,
+	"{\n  \"a\": 1, // comment\n  \"b\": 2\n}",
+	"```json\n{\"name\": \"John\"}\n```",
+	`"hello" + " world"`,
+	`{"_id": ObjectId("123"), "count": NumberLong("456")}`,
+	`{"ts": Timestamp(1234, 1)}`,
+	`callback({"a": 1});`,
+	"{\"id\":1}\n{\"id\":2}\n{\"id\":3}",
+	"{\"id\":1},\n{\"id\":2},",
+	`{"enabled": True, "data": None}`,
+	`{"regex": /[a-z]+/}`,
+	`[1, 2, 3, ...]`,
+	`{"a": "hello "world"", "b": 2}`,
+	`{"url": http://example.com/path?q=1}`,
+	`{"value":0789}`,
+	`[2., -, 3e]`,
+	`{"a":2}]]`,
+	`{\"stringified\": \"content\"}`,
+	`"☎ \\u2605 ★ 😀"`,
+	"{ \"a\":　\"b\"}",
+	`{"a": "b",`,
+	`[{"a": 1}, {"b": [1, {"c": "d"`,
+	"{\"doc\": \"\"\"multi-line\n\"text\"\"\"\", '''k''': 1}",
+}
+
+func repairStreamString(t *testing.T, input string, r io.Reader) (string, error) {
+	t.Helper()
+	var out bytes.Buffer
+	err := RepairStream(r, &out)
+	return out.String(), err
+}
+
+func TestRepairStream(t *testing.T) {
+	for _, input := range streamTestInputs {
+		expected, expectedErr := JSONRepair(input)
+
+		result, err := repairStreamString(t, input, iotest.OneByteReader(strings.NewReader(input)))
+		if (err == nil) != (expectedErr == nil) {
+			t.Errorf("RepairStream(%q) error = %v, want %v", input, err, expectedErr)
+			continue
+		}
+		if err == nil && result != expected {
+			t.Errorf("RepairStream(%q) = %q, want %q", input, result, expected)
+		}
+	}
+}
+
+func TestRepairStreamErrors(t *testing.T) {
+	inputs := []string{"", `{"a":2}{}`, `{"a":2}foo`, `foo [`}
+	for _, input := range inputs {
+		_, expectedErr := JSONRepair(input)
+		_, err := repairStreamString(t, input, iotest.HalfReader(strings.NewReader(input)))
+
+		var expected, actual *JSONRepairError
+		if !errors.As(expectedErr, &expected) || !errors.As(err, &actual) {
+			t.Errorf("RepairStream(%q) error = %v, want %v", input, err, expectedErr)
+			continue
+		}
+		if *actual != *expected {
+			t.Errorf("RepairStream(%q) error = %v, want %v", input, actual, expected)
+		}
+	}
+
+	t.Run("read error", func(t *testing.T) {
+		readErr := errors.New("read failed")
+		r := io.MultiReader(strings.NewReader(`[1, 2`), iotest.ErrReader(readErr))
+		_, err := repairStreamString(t, "", r)
+		if !errors.Is(err, readErr) {
+			t.Errorf("Expected read error, got %v", err)
+		}
+	})
+}
+
+func TestRepairStreamLargeInput(t *testing.T) {
+	defer func(chunk, buffer int) {
+		streamChunkSize, streamBufferSize = chunk, buffer
+	}(streamChunkSize, streamBufferSize)
+	streamChunkSize, streamBufferSize = 16, 32
+
+	var input strings.Builder
+	input.WriteString("[\n")
+	for i := 0; i < 500; i++ {
+		input.WriteString("  {id: 1, name: 'item' \"tags\": ['a', 'b',]},\n")
+	}
+	input.WriteString("  {id: 2, /* last */ name: 'end'")
+
+	expected, err := JSONRepair(input.String())
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	result, err := repairStreamString(t, input.String(), iotest.OneByteReader(strings.NewReader(input.String())))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if result != expected {
+		t.Errorf("RepairStream differs from JSONRepair:\n%s\nwant:\n%s", result, expected)
+	}
+
+	t.Run("newline delimited", func(t *testing.T) {
+		var input strings.Builder
+		for i := 0; i < 200; i++ {
+			input.WriteString("{\"id\": 1, \"list\": [1, 2, 3]}\n")
+		}
+		expected, _ := JSONRepair(input.String())
+		result, err := repairStreamString(t, input.String(), strings.NewReader(input.String()))
+		if err != nil {
+			t.Fatal(err)
+		}
+		if result != expected {
+			t.Errorf("RepairStream differs from JSONRepair:\n%s\nwant:\n%s", result, expected)
+		}
+	})
+}
+
+// notifyWriter signals on written the first time it is written to
+type notifyWriter struct {
+	buf     bytes.Buffer
+	written chan struct{}
+}
+
+func (w *notifyWriter) Write(b []byte) (int, error) {
+	if w.buf.Len() == 0 {
+		close(w.written)
+	}
+	return w.buf.Write(b)
+}
+
+func TestRepairStreamFlushesEarly(t *testing.T) {
+	defer func(chunk, buffer int) {
+		streamChunkSize, streamBufferSize = chunk, buffer
+	}(streamChunkSize, streamBufferSize)
+	streamChunkSize, streamBufferSize = 16, 32
+
+	pr, pw := io.Pipe()
+	out := &notifyWriter{written: make(chan struct{})}
+	done := make(chan error)
+	go func() {
+		done <- RepairStream(pr, out)
+	}()
+
+	prefix := "[" + strings.Repeat(`{"a": 1}, `, 50)
+	if _, err := io.WriteString(pw, prefix); err != nil {
+		t.Fatal(err)
+	}
+
+	// The input is still open, so output can only arrive if it is flushed early
+	select {
+	case <-out.written:
+	case <-time.After(5 * time.Second):
+		t.Fatal("No output written before the end of the input")
+	}
+
+	pw.Close()
+	if err := <-done; err != nil {
+		t.Fatal(err)
+	}
+	if expected := MustJSONRepair(prefix); out.buf.String() != expected {
+		t.Errorf("Expected %q, got %q", expected, out.buf.String())
+	}
+}
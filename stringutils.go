@@ -8,10 +8,9 @@ import (
 
 // Compiled regular expressions
 var (
-	startOfValueRegex   = regexp.MustCompile(`^[[\{\w-]$`)
-	urlStartRegex       = regexp.MustCompile(`^(http|https|ftp|mailto|file|data|irc)://$`)
-	urlCharRegex        = regexp.MustCompile(`^[A-Za-z0-9\-._~:/?#@!$&'()*+;=]$`)
-	commaOrNewlineRegex = regexp.MustCompile(`[,\n][ \t\r]*$`)
+	startOfValueRegex = regexp.MustCompile(`^[[\{\w-]$`)
+	urlStartRegex     = regexp.MustCompile(`^(http|https|ftp|mailto|file|data|irc)://$`)
+	urlCharRegex      = regexp.MustCompile(`^[A-Za-z0-9\-._~:/?#@!$&'()*+;=]$`)
 )
 
 // isHex checks if a character is a hexadecimal digit
@@ -19,6 +18,16 @@ func isHex(char rune) bool {
 	return (char >= '0' && char <= '9') || (char >= 'A' && char <= 'F') || (char >= 'a' && char <= 'f')
 }
 
+// isHexString checks if a string only holds hexadecimal digits
+func isHexString(text string) bool {
+	for _, char := range text {
+		if !isHex(char) {
+			return false
+		}
+	}
+	return true
+}
+
 // isDigit checks if a character is a digit
 func isDigit(char rune) bool {
 	return char >= '0' && char <= '9'
@@ -121,56 +130,6 @@ func isSingleQuote(char rune) bool {
 	return char == '\''
 }
 
-// stripLastOccurrence removes the last occurrence of a substring
-func stripLastOccurrence(text, textToStrip string, stripRemainingText bool) string {
-	index := strings.LastIndex(text, textToStrip)
-	if index == -1 {
-		return text
-	}
-	if stripRemainingText {
-		return text[:index]
-	}
-	// Note: Original TypeScript uses index + 1, not index + textToStrip.length
-	// This works correctly for single character strings
-	return text[:index] + text[index+1:]
-}
-
-// insertBeforeLastWhitespace inserts text before the last whitespace characters
-func insertBeforeLastWhitespace(text, textToInsert string) string {
-	index := len(text)
-
-	// If no trailing whitespace, append at the end
-	if index == 0 || !isWhitespace(text, index-1) {
-		return text + textToInsert
-	}
-
-	// Find the start of trailing whitespace
-	for index > 0 && isWhitespace(text, index-1) {
-		// Move back by the size of the character
-		_, size := utf8.DecodeLastRuneInString(text[:index])
-		index -= size
-	}
-
-	return text[:index] + textToInsert + text[index:]
-}
-
-// removeAtIndex removes count characters starting at the given index
-func removeAtIndex(text string, start, count int) string {
-	if start >= len(text) {
-		return text
-	}
-	end := start + count
-	if end > len(text) {
-		end = len(text)
-	}
-	return text[:start] + text[end:]
-}
-
-// endsWithCommaOrNewline checks if text ends with a comma or newline (with optional whitespace)
-func endsWithCommaOrNewline(text string) bool {
-	return commaOrNewlineRegex.MatchString(text)
-}
-
 // atEndOfBlockComment checks if we're at the end of a block comment
 func atEndOfBlockComment(text string, i int) bool {
 	return i < len(text)-1 && text[i] == '*' && text[i+1] == '/'
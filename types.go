@@ -5,14 +5,26 @@
 package jsonrepair
 
 import (
+	"context"
 	"fmt"
-	"strings"
+	"io"
 )
 
 // JSONRepairError represents an error that occurred during JSON repair
 type JSONRepairError struct {
+	Code     Code // Reason of the error, zero when unknown
 	Message  string
-	Position int
+	Position int // Byte offset in the input
+
+	// Location of the error for display in editors and tools. They are
+	// filled in by the parser; errors created with NewJSONRepairError
+	// leave them empty.
+	Line    int    // Line number, starting at 1
+	Column  int    // Column in runes, starting at 1
+	Snippet string // Excerpt of the line with a caret marking the column below it
+	Path    string // JSON path of the enclosing container, like $.users[2]
+
+	Err error // Underlying error, like the error of a canceled context
 }
 
 // Error implements the error interface
@@ -20,6 +32,11 @@ func (e *JSONRepairError) Error() string {
 	return fmt.Sprintf("%s at position %d", e.Message, e.Position)
 }
 
+// Unwrap returns the underlying error, if any
+func (e *JSONRepairError) Unwrap() error {
+	return e.Err
+}
+
 // NewJSONRepairError creates a new JSONRepairError
 func NewJSONRepairError(message string, position int) *JSONRepairError {
 	return &JSONRepairError{
@@ -30,9 +47,86 @@ func NewJSONRepairError(message string, position int) *JSONRepairError {
 
 // Parser represents a JSON repair parser
 type Parser struct {
-	text   string          // Input text to parse
-	output strings.Builder // Output buffer for repaired JSON
-	i      int             // Current position index in text
+	text   string       // Input text to parse (buffered window when streaming)
+	output outputBuffer // Output buffer for repaired JSON
+	i      int          // Current position index in text
+
+	reader  io.Reader // Source of further input when streaming, nil otherwise
+	buf     []byte    // Backing storage of text when streaming
+	eof     bool      // Whether reader has been drained
+	growing bool      // Whether text may grow, when streaming or repairing partial input
+	offset  int       // Number of input bytes discarded before text
+	hold    int       // Nesting of regions whose output may still be rolled back
+	err     error     // First read error encountered while streaming
+
+	disabled  uint64   // Bit set of the repair kinds that must not be applied
+	reporting bool     // Whether to record every repair rather than only disabled ones
+	repairs   []Repair // Repairs recorded so far
+	checked   int      // Number of repairs already checked against disabled
+	comma     int      // Input offset of the last comma parsed as a separator
+	format    Format   // How whitespace is written to the output
+	indent    string   // Indentation of one level with FormatIndent
+	dialect   Dialect  // Language the input is written in
+	raw       bool     // Whether backslashes are literal in the string being parsed, like in a Python r'' string
+
+	fastPathDisabled bool // Whether valid input is parsed like any other input
+	extracting       bool // Whether to stop after the first value, leaving the text after it
+
+	maxDepth  int // Maximum nesting of containers, DefaultMaxDepth when zero and none when negative
+	maxInput  int // Maximum input size in bytes, none when zero
+	maxString int // Maximum input length of a string in bytes, none when zero
+	maxOutput int // Maximum output size in bytes, none when zero
+
+	ctx   context.Context // Checked for cancellation while parsing, nil when not cancelable
+	steps int             // Loop iterations since ctx was last checked
+
+	duplicates DuplicateKeys // How duplicate keys are resolved
+	keys       []int         // Input offsets of the object keys written so far, with a duplicate key policy
+	nonFinite  NonFinite     // How NaN and Infinity are written
+
+	lines    int    // Number of newlines in the input discarded while streaming
+	column   int    // Number of runes after the last newline in the discarded input
+	failPos  int    // Input offset where a container last failed to parse
+	failPath string // Path of the container that failed at failPos
+
+	stack      []frame     // Containers enclosing the current position
+	partial    bool        // Whether more input may be appended to text later
+	atEdge     bool        // Whether the parser has looked at the end of partial input
+	checkpoint *checkpoint // Last position the parse can be resumed from
+}
+
+// marker records a state of the parser that it may roll back to
+type marker struct {
+	i       int    // Position in the input
+	output  int    // Length of the output
+	repairs int    // Number of repairs
+	keys    int    // Number of object keys
+	pending string // Whitespace to write before the next token
+	open    bool   // Whether the innermost container has no member written yet
+}
+
+// bailout is raised with panic by Parser.fail to abort parsing
+type bailout struct {
+	err error
+}
+
+// frameKind identifies a container the parser is inside of
+type frameKind int
+
+const (
+	frameObject           frameKind = iota // members of an object
+	frameArray                             // items of an array
+	frameNewlineDelimited                  // values of newline delimited JSON
+	frameFunctionCall                      // first argument of a function call
+)
+
+// frame holds the state of a container being parsed
+type frame struct {
+	kind    frameKind
+	key     string // Current key of an object, as written to the output
+	index   int    // Current index of an array
+	closing byte   // End bracket of a Python tuple or set parsed as an array, zero for ]
+	initial bool   // whether no member has been parsed yet, as of the last safe point
 }
 
 // NewParser creates a new Parser instance
@@ -0,0 +1,220 @@
+package jsonrepair
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"strings"
+	"unsafe"
+)
+
+// DecodeError is returned by Unmarshal and Decoder.Decode when the repaired
+// document cannot be decoded into the destination value
+type DecodeError struct {
+	Path   string // JSON path of the value that failed to decode, like $.items[2].id
+	Offset int    // Byte offset in the repaired document
+	Err    error  // Error returned by encoding/json
+}
+
+// Error implements the error interface
+func (e *DecodeError) Error() string {
+	return fmt.Sprintf("%v at %s", e.Err, e.Path)
+}
+
+// Unwrap returns the error returned by encoding/json
+func (e *DecodeError) Unwrap() error {
+	return e.Err
+}
+
+// Unmarshal repairs the JSON document in data and decodes it into the value
+// pointed to by v, like json.Unmarshal. Repair errors are returned as a
+// *JSONRepairError, decode errors as a *DecodeError.
+//
+// Example:
+//
+//	var user struct{ Name string }
+//	err := jsonrepair.Unmarshal([]byte("{name: 'John'}"), &user)
+func Unmarshal(data []byte, v any) error {
+	return UnmarshalWithOptions(data, v, Options{})
+}
+
+// UnmarshalWithOptions is like Unmarshal, applying only the repairs allowed
+// by opts
+func UnmarshalWithOptions(data []byte, v any, opts Options) error {
+	// The parser only reads its input, so data can be used without a copy
+	parser := NewParserWithOptions(unsafe.String(unsafe.SliceData(data), len(data)), opts)
+	output, err := parser.Parse()
+	if err != nil {
+		return err
+	}
+	return decode(unsafe.Slice(unsafe.StringData(output), len(output)), v, json.NewDecoder)
+}
+
+// Decoder reads a JSON document from an input stream, repairs it and
+// decodes it into a Go value, like json.Decoder. The input is repaired as
+// a single document: newline delimited JSON is decoded as an array.
+type Decoder struct {
+	r    io.Reader
+	opts Options
+	done bool
+
+	useNumber             bool
+	disallowUnknownFields bool
+}
+
+// NewDecoder creates a Decoder reading from r
+func NewDecoder(r io.Reader) *Decoder {
+	return &Decoder{r: r}
+}
+
+// NewDecoderWithOptions creates a Decoder reading from r, applying only
+// the repairs allowed by opts
+func NewDecoderWithOptions(r io.Reader, opts Options) *Decoder {
+	return &Decoder{r: r, opts: opts}
+}
+
+// UseNumber causes numbers to be decoded into an interface{} as a
+// json.Number instead of a float64
+func (d *Decoder) UseNumber() {
+	d.useNumber = true
+}
+
+// DisallowUnknownFields causes an error when the destination is a struct
+// and the document contains a key that does not match any field
+func (d *Decoder) DisallowUnknownFields() {
+	d.disallowUnknownFields = true
+}
+
+// Decode reads and repairs the whole input and decodes it into the value
+// pointed to by v. Once the input has been decoded, Decode returns io.EOF.
+func (d *Decoder) Decode(v any) error {
+	if d.done {
+		return io.EOF
+	}
+	d.done = true
+
+	var output bytes.Buffer
+	if err := repairStream(d.r, &output, d.opts); err != nil {
+		return err
+	}
+	return decode(output.Bytes(), v, func(r io.Reader) *json.Decoder {
+		dec := json.NewDecoder(r)
+		if d.useNumber {
+			dec.UseNumber()
+		}
+		if d.disallowUnknownFields {
+			dec.DisallowUnknownFields()
+		}
+		return dec
+	})
+}
+
+// decode decodes the repaired document data into v with a json.Decoder
+// created by newDecoder, adding the path to decode errors
+func decode(data []byte, v any, newDecoder func(io.Reader) *json.Decoder) error {
+	err := newDecoder(bytes.NewReader(data)).Decode(v)
+	if err == nil {
+		return nil
+	}
+
+	var offset int64
+	var typeErr *json.UnmarshalTypeError
+	var syntaxErr *json.SyntaxError
+	switch {
+	case errors.As(err, &typeErr):
+		offset = typeErr.Offset
+	case errors.As(err, &syntaxErr):
+		offset = syntaxErr.Offset
+	default:
+		return err
+	}
+	return &DecodeError{Path: pathAt(data, offset), Offset: int(offset), Err: err}
+}
+
+// syntaxError returns the error of encoding/json for the repaired
+// document data, which is not valid JSON, as a *DecodeError
+func syntaxError(data []byte) error {
+	err := json.Unmarshal(data, new(json.RawMessage))
+	var offset int64
+	var syntaxErr *json.SyntaxError
+	if errors.As(err, &syntaxErr) {
+		offset = syntaxErr.Offset
+	}
+	return &DecodeError{Path: pathAt(data, offset), Offset: int(offset), Err: err}
+}
+
+// pathAt returns the JSON path of the value in the document data that is
+// being read at offset
+func pathAt(data []byte, offset int64) string {
+	type level struct {
+		object    bool
+		expectKey bool
+		key       string
+		index     int
+	}
+	var stack []level
+
+	format := func() string {
+		var b strings.Builder
+		b.WriteString("$")
+		for _, l := range stack {
+			if l.object {
+				writePathKey(&b, l.key)
+			} else {
+				writePathIndex(&b, l.index)
+			}
+		}
+		return b.String()
+	}
+
+	// valueDone advances the parent container past a value
+	valueDone := func() {
+		if n := len(stack); n > 0 {
+			if stack[n-1].object {
+				stack[n-1].expectKey = true
+			} else {
+				stack[n-1].index++
+			}
+		}
+	}
+
+	dec := json.NewDecoder(bytes.NewReader(data))
+	for {
+		tok, err := dec.Token()
+		if err != nil {
+			break
+		}
+
+		top := len(stack) - 1
+		if top >= 0 && stack[top].object && stack[top].expectKey {
+			if key, ok := tok.(string); ok {
+				stack[top].key = key
+				stack[top].expectKey = false
+				continue
+			}
+		}
+
+		switch tok {
+		case json.Delim('}'), json.Delim(']'):
+			stack = stack[:top]
+			valueDone()
+			continue
+		}
+
+		// tok starts a value
+		if dec.InputOffset() >= offset {
+			break
+		}
+		switch tok {
+		case json.Delim('{'):
+			stack = append(stack, level{object: true, expectKey: true})
+		case json.Delim('['):
+			stack = append(stack, level{})
+		default:
+			valueDone()
+		}
+	}
+	return format()
+}
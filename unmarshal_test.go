@@ -0,0 +1,110 @@
+package jsonrepair
+
+import (
+	"encoding/json"
+	"errors"
+	"io"
+	"reflect"
+	"strings"
+	"testing"
+)
+
+type unmarshalUser struct {
+	Name string   `json:"name"`
+	Age  int      `json:"age"`
+	Tags []string `json:"tags"`
+}
+
+func TestUnmarshal(t *testing.T) {
+	var user unmarshalUser
+	err := Unmarshal([]byte("{name: 'John', age: 30, tags: ['a', 'b',], // comment\n}"), &user)
+	if err != nil {
+		t.Fatalf("Unmarshal returned error: %v", err)
+	}
+	expected := unmarshalUser{Name: "John", Age: 30, Tags: []string{"a", "b"}}
+	if !reflect.DeepEqual(user, expected) {
+		t.Errorf("Unmarshal = %+v, want %+v", user, expected)
+	}
+
+	var values []any
+	if err := Unmarshal([]byte("{\"id\":1}\n{\"id\":2}"), &values); err != nil {
+		t.Fatalf("Unmarshal returned error: %v", err)
+	}
+	if len(values) != 2 {
+		t.Errorf("Unmarshal NDJSON = %v, want 2 values", values)
+	}
+}
+
+func TestUnmarshalErrors(t *testing.T) {
+	var user unmarshalUser
+	err := Unmarshal([]byte(`{"name": "John"}}x`), &user)
+	if !errors.Is(err, ErrUnexpectedCharacter) {
+		t.Errorf("Unmarshal error = %v, want ErrUnexpectedCharacter", err)
+	}
+
+	err = UnmarshalWithOptions([]byte(`{name: "John"}`), &user, Options{Disable: []RepairKind{RepairUnquotedString}})
+	if !errors.Is(err, ErrRepairDisabled) {
+		t.Errorf("UnmarshalWithOptions error = %v, want ErrRepairDisabled", err)
+	}
+
+	tests := []struct {
+		input string
+		v     any
+		path  string
+	}{
+		{`{name: "John", age: 'thirty'}`, &unmarshalUser{}, "$.age"},
+		{`{name: "John", tags: ['a', 2]}`, &unmarshalUser{}, "$.tags[1]"},
+		{`[{name: "a"}, {name: "b", age: [1]}]`, &[]unmarshalUser{}, "$[1].age"},
+		{`{"a b": {"c": true}}`, &map[string]map[string]int{}, `$["a b"].c`},
+		{`"text"`, new(int), "$"},
+	}
+	for _, tt := range tests {
+		err := Unmarshal([]byte(tt.input), tt.v)
+		var decodeErr *DecodeError
+		if !errors.As(err, &decodeErr) {
+			t.Errorf("Unmarshal(%q): expected *DecodeError, got %v", tt.input, err)
+			continue
+		}
+		if decodeErr.Path != tt.path {
+			t.Errorf("Unmarshal(%q) error path = %q, want %q", tt.input, decodeErr.Path, tt.path)
+		}
+		var typeErr *json.UnmarshalTypeError
+		if !errors.As(err, &typeErr) {
+			t.Errorf("Unmarshal(%q) error does not wrap *json.UnmarshalTypeError: %v", tt.input, err)
+		}
+	}
+}
+
+func TestDecoder(t *testing.T) {
+	dec := NewDecoder(strings.NewReader("{name: 'John', extra: 1.5}"))
+	dec.UseNumber()
+	var value map[string]any
+	if err := dec.Decode(&value); err != nil {
+		t.Fatalf("Decode returned error: %v", err)
+	}
+	if value["name"] != "John" || value["extra"] != json.Number("1.5") {
+		t.Errorf("Decode = %v", value)
+	}
+	if err := dec.Decode(&value); err != io.EOF {
+		t.Errorf("second Decode = %v, want io.EOF", err)
+	}
+
+	dec = NewDecoder(strings.NewReader("{name: 'John', extra: 1}"))
+	dec.DisallowUnknownFields()
+	var user unmarshalUser
+	if err := dec.Decode(&user); err == nil {
+		t.Error("expected an error for an unknown field")
+	}
+
+	dec = NewDecoderWithOptions(strings.NewReader("{name: 'John'}"), Options{Disable: []RepairKind{RepairQuotes}})
+	if err := dec.Decode(&user); !errors.Is(err, ErrRepairDisabled) {
+		t.Errorf("Decode error = %v, want ErrRepairDisabled", err)
+	}
+
+	dec = NewDecoder(strings.NewReader("[1, 'x']"))
+	var numbers []int
+	var decodeErr *DecodeError
+	if err := dec.Decode(&numbers); !errors.As(err, &decodeErr) || decodeErr.Path != "$[1]" {
+		t.Errorf("Decode error = %v, want a *DecodeError at $[1]", err)
+	}
+}
@@ -0,0 +1,240 @@
+package jsonrepair
+
+import "unicode/utf8"
+
+// unchanged reports whether the input is strict JSON that repairing would
+// return as is, so that Parse can skip the parser. It is only the case
+// when the whitespace of the input is kept and duplicate keys are allowed.
+// Input exceeding a limit is left to the parser to report the error.
+func (p *Parser) unchanged() bool {
+	if p.fastPathDisabled || p.format != FormatPreserve || p.duplicates != DuplicateKeysAllow {
+		return false
+	}
+	if p.reader != nil || p.partial || p.i != 0 {
+		return false
+	}
+	if p.maxInput > 0 && len(p.text) > p.maxInput || p.maxOutput > 0 && len(p.text) > p.maxOutput {
+		return false
+	}
+	v := validator{text: p.text, maxDepth: p.depthLimit(), maxString: p.maxString}
+	return v.valid()
+}
+
+// validator scans a strict JSON document in a single pass over its bytes,
+// without building anything
+type validator struct {
+	text      string
+	i         int
+	maxDepth  int // Maximum nesting of containers, none when not positive
+	maxString int // Maximum length of a string including its quotes, none when zero
+}
+
+// valid reports whether the text is a single JSON value surrounded by
+// optional whitespace
+func (v *validator) valid() bool {
+	// Open brackets of the enclosing containers, kept in a local array
+	// unless they are nested deeply
+	var buf [64]byte
+	stack := buf[:0]
+
+	for {
+		v.skipWhitespace()
+		opened, ok := v.value(len(stack))
+		if !ok {
+			return false
+		}
+		if opened != 0 {
+			stack = append(stack, opened)
+			continue
+		}
+
+		// Close the containers ending here, up to the next member
+		for {
+			v.skipWhitespace()
+			if len(stack) == 0 {
+				return v.i == len(v.text)
+			}
+			if v.i >= len(v.text) {
+				return false
+			}
+			open := stack[len(stack)-1]
+			if v.text[v.i] == ',' {
+				v.i++
+				if open == '{' && !v.key() {
+					return false
+				}
+				break
+			}
+			if v.text[v.i] != closingBracket(open) {
+				return false
+			}
+			stack = stack[:len(stack)-1]
+			v.i++
+		}
+	}
+}
+
+// value scans a value at the given depth. When the value is a non-empty
+// object or array, it only scans up to its first member value and returns
+// the bracket it opened.
+func (v *validator) value(depth int) (opened byte, ok bool) {
+	if v.i >= len(v.text) {
+		return 0, false
+	}
+	switch c := v.text[v.i]; c {
+	case '{', '[':
+		if v.maxDepth > 0 && depth >= v.maxDepth {
+			return 0, false
+		}
+		v.i++
+		v.skipWhitespace()
+		if v.i < len(v.text) && v.text[v.i] == closingBracket(c) {
+			v.i++
+			return 0, true
+		}
+		return c, c == '[' || v.key()
+	case '"':
+		return 0, v.string()
+	case 't':
+		return 0, v.literal("true")
+	case 'f':
+		return 0, v.literal("false")
+	case 'n':
+		return 0, v.literal("null")
+	default:
+		return 0, v.number()
+	}
+}
+
+// key scans an object key and the colon after it
+func (v *validator) key() bool {
+	v.skipWhitespace()
+	if v.i >= len(v.text) || v.text[v.i] != '"' || !v.string() {
+		return false
+	}
+	v.skipWhitespace()
+	if v.i >= len(v.text) || v.text[v.i] != ':' {
+		return false
+	}
+	v.i++
+	return true
+}
+
+// string scans a string starting at its opening quote
+func (v *validator) string() bool {
+	start := v.i
+	v.i++
+	for v.i < len(v.text) {
+		c := v.text[v.i]
+		switch {
+		case c == '"':
+			v.i++
+			return v.maxString <= 0 || v.i-start <= v.maxString
+		case c == '\\':
+			if !v.escape() {
+				return false
+			}
+		case c < 0x20:
+			return false
+		case c < utf8.RuneSelf:
+			v.i++
+		default:
+			r, size := utf8.DecodeRuneInString(v.text[v.i:])
+			if r == utf8.RuneError && size == 1 {
+				return false
+			}
+			v.i += size
+		}
+	}
+	return false
+}
+
+// escape scans an escape sequence inside a string
+func (v *validator) escape() bool {
+	if v.i+1 >= len(v.text) {
+		return false
+	}
+	switch v.text[v.i+1] {
+	case '"', '\\', '/', 'b', 'f', 'n', 'r', 't':
+		v.i += 2
+		return true
+	case 'u':
+		if v.i+6 > len(v.text) {
+			return false
+		}
+		for j := v.i + 2; j < v.i+6; j++ {
+			if !isHex(rune(v.text[j])) {
+				return false
+			}
+		}
+		v.i += 6
+		return true
+	}
+	return false
+}
+
+// number scans a number
+func (v *validator) number() bool {
+	if v.i < len(v.text) && v.text[v.i] == '-' {
+		v.i++
+	}
+	if v.i < len(v.text) && v.text[v.i] == '0' {
+		v.i++
+	} else if !v.digits() {
+		return false
+	}
+	if v.i < len(v.text) && v.text[v.i] == '.' {
+		v.i++
+		if !v.digits() {
+			return false
+		}
+	}
+	if v.i < len(v.text) && (v.text[v.i] == 'e' || v.text[v.i] == 'E') {
+		v.i++
+		if v.i < len(v.text) && (v.text[v.i] == '+' || v.text[v.i] == '-') {
+			v.i++
+		}
+		if !v.digits() {
+			return false
+		}
+	}
+	return true
+}
+
+// digits scans one or more digits
+func (v *validator) digits() bool {
+	start := v.i
+	for v.i < len(v.text) && v.text[v.i] >= '0' && v.text[v.i] <= '9' {
+		v.i++
+	}
+	return v.i > start
+}
+
+// literal scans the keyword word
+func (v *validator) literal(word string) bool {
+	if len(v.text)-v.i < len(word) || v.text[v.i:v.i+len(word)] != word {
+		return false
+	}
+	v.i += len(word)
+	return true
+}
+
+// skipWhitespace skips the whitespace allowed between JSON tokens
+func (v *validator) skipWhitespace() {
+	for v.i < len(v.text) {
+		switch v.text[v.i] {
+		case ' ', '\t', '\n', '\r':
+			v.i++
+		default:
+			return
+		}
+	}
+}
+
+// closingBracket returns the bracket closing open
+func closingBracket(open byte) byte {
+	if open == '{' {
+		return '}'
+	}
+	return ']'
+}
@@ -0,0 +1,139 @@
+package jsonrepair
+
+import (
+	"encoding/json"
+	"strings"
+	"testing"
+	"unsafe"
+)
+
+func TestValidator(t *testing.T) {
+	valid := []string{
+		`null`, `true`, `false`, `0`, `-0`, `12.5e-3`, `1E+10`, `""`,
+		`"\" \\ \/ \b \f \n \r \t é"`, `"😀 é"`,
+		`{}`, `[]`, ` { "a" : [ 1 , { } , [ ] ] } `, "[\n\t1,\r\n\t2\n]",
+		`{"a":{"b":{"c":[[["d"]]]}},"e":null}`,
+		`["a",true,"b"]`,
+	}
+	invalid := []string{
+		``, ` `, `nul`, `True`, `01`, `1.`, `.5`, `-`, `1e`, `+1`, `NaN`,
+		`"abc`, `'abc'`, `"\x"`, `"\u12"`, "\"\t\"", "\"\xff\"",
+		`{`, `[1,]`, `[1 2]`, `{"a"}`, `{"a":}`, `{a:1}`, `{"a":1,}`, `[1]]`, `{"a":1]`,
+		`[1] [2]`, `// comment` + "\n1", " 1",
+	}
+	for _, text := range valid {
+		v := validator{text: text}
+		if !v.valid() {
+			t.Errorf("valid(%q) = false, want true", text)
+		}
+		if !json.Valid([]byte(text)) {
+			t.Errorf("encoding/json rejects test case %q", text)
+		}
+	}
+	for _, text := range invalid {
+		v := validator{text: text}
+		if v.valid() {
+			t.Errorf("valid(%q) = true, want false", text)
+		}
+	}
+
+	v := validator{text: `[[1]]`, maxDepth: 1}
+	if v.valid() {
+		t.Errorf("valid(%q) with maximum depth 1 = true, want false", v.text)
+	}
+	v = validator{text: `["abc"]`, maxString: 4}
+	if v.valid() {
+		t.Errorf("valid(%q) with maximum string length 4 = true, want false", v.text)
+	}
+}
+
+func TestFastPath(t *testing.T) {
+	input := `{"name": "John", "tags": ["a", "b"]}`
+	output, err := JSONRepair(input)
+	if err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	if output != input {
+		t.Errorf("JSONRepair(%q) = %q", input, output)
+	}
+	if unsafe.StringData(output) != unsafe.StringData(input) {
+		t.Errorf("JSONRepair copied valid input")
+	}
+
+	// Options changing valid input skip the fast path
+	tests := []struct {
+		opts     Options
+		expected string
+	}{
+		{Options{Format: FormatCompact}, `{"name":"John","tags":["a","b"]}`},
+		{Options{Format: FormatCanonical}, `{"name":"John","tags":["a","b"]}`},
+		{Options{DuplicateKeys: DuplicateKeysFirst}, input},
+		{Options{DisableFastPath: true}, input},
+	}
+	for _, tt := range tests {
+		output, err := JSONRepairWithOptions(input, tt.opts)
+		if err != nil || output != tt.expected {
+			t.Errorf("JSONRepairWithOptions(%q, %+v) = %q, %v, want %q", input, tt.opts, output, err, tt.expected)
+		}
+	}
+	output, err = JSONRepairWithOptions(`{"a": 1, "a": 2}`, Options{DuplicateKeys: DuplicateKeysLast})
+	if err != nil || output != `{"a": 2}` {
+		t.Errorf("Duplicate keys of valid input not resolved: %q, %v", output, err)
+	}
+
+	// Limits are still reported by the parser
+	limits := []Options{
+		{MaxDepth: 1},
+		{MaxInputBytes: 10},
+		{MaxOutputBytes: 10},
+		{MaxStringLength: 3},
+	}
+	for _, opts := range limits {
+		if _, err := JSONRepairWithOptions(input, opts); err == nil {
+			t.Errorf("JSONRepairWithOptions(%q, %+v) exceeded no limit", input, opts)
+		}
+	}
+}
+
+func TestResultChanged(t *testing.T) {
+	tests := []struct {
+		input   string
+		opts    Options
+		changed bool
+	}{
+		{`{"a": [1, 2]}`, Options{}, false},
+		{`{"a": [1, 2]}`, Options{DisableFastPath: true}, false},
+		{`{"a": [1, 2]}`, Options{Format: FormatCompact}, true},
+		{`{"a": [1, 2]`, Options{}, true},
+		{`{a: [1, 2]}`, Options{}, true},
+	}
+	for _, tt := range tests {
+		result, err := JSONRepairWithReport(tt.input, tt.opts)
+		if err != nil {
+			t.Errorf("JSONRepairWithReport(%q) error: %v", tt.input, err)
+			continue
+		}
+		if result.Changed != tt.changed {
+			t.Errorf("JSONRepairWithReport(%q, %+v).Changed = %v, want %v", tt.input, tt.opts, result.Changed, tt.changed)
+		}
+	}
+}
+
+func BenchmarkFastPath(b *testing.B) {
+	input := "[" + strings.Repeat(`{"id": 1, "name": "John", "tags": ["a", "b"], "score": -1.5e3}, `, 10000) + "null]"
+	for _, disabled := range []bool{false, true} {
+		name := "fast path"
+		if disabled {
+			name = "parser"
+		}
+		b.Run(name, func(b *testing.B) {
+			b.SetBytes(int64(len(input)))
+			opts := Options{DisableFastPath: disabled}
+			for i := 0; i < b.N; i++ {
+				if _, err := JSONRepairWithOptions(input, opts); err != nil {
+					b.Fatal(err)
+				}
+			}
+		})
+	}
+}